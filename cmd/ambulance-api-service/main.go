@@ -5,8 +5,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // time zones of the ambulances, the container image has no tz database
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,6 +19,7 @@ import (
 	"github.com/xlukacs/ambulance-webapi/api"
 	"github.com/xlukacs/ambulance-webapi/internal/ambulance_wl"
 	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+	"github.com/xlukacs/ambulance-webapi/internal/health"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/prometheus"
@@ -40,51 +45,226 @@ func main() {
 	engine := gin.New()
 	engine.Use(gin.Recovery())
 
+	// service is not ready until fully initialized
+	serviceHealth := health.New()
+
 	corsMiddleware := cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "PUT", "POST", "DELETE", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{""},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Last-Event-ID"},
+		ExposeHeaders:    []string{"X-Total-Count"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	})
 	engine.Use(corsMiddleware)
 
 	// setup telemetry
-	shutdown, err := initTelemetry()
+	shutdownTelemetry, err := initTelemetry()
 	if err != nil {
 		log.Fatalf("Failed to initialize telemetry: %v", err)
 	}
-	defer func() { _ = shutdown(context.Background()) }()
 	engine.Use(
 		otelginmetrics.Middleware(
 			"Ambulance WebAPI Service",
 			// Custom attributes
 			otelginmetrics.WithAttributes(func(serverName, route string, request *http.Request) []attribute.KeyValue {
-				return append(otelginmetrics.DefaultAttributes(serverName, route, request))
+				return otelginmetrics.DefaultAttributes(serverName, route, request)
 			}),
 		),
 		// otelgin.Middleware(serverName), TODO this needs to be here, but where is the serverName coming from...???
 	)
 
 	// setup context update  middleware
-	dbService := db_service.NewMongoService[ambulance_wl.Ambulance](db_service.MongoServiceConfig{})
-	defer dbService.Disconnect(context.Background())
+	var dbService db_service.DbService[ambulance_wl.Ambulance]
+	// archive of the finished visits is kept in a separate collection
+	var visitsDbService db_service.DbService[ambulance_wl.Visit]
+	// registry of the patients referenced by the waiting lists
+	var patientsDbService db_service.DbService[ambulance_wl.Patient]
+	// hospital-wide catalogue of the conditions, filled by the condition-catalogue-import command
+	var catalogueDbService db_service.DbService[ambulance_wl.CatalogueCondition]
+	switch backend := os.Getenv("AMBULANCE_API_DB_BACKEND"); strings.ToLower(backend) {
+	case "", "mongo", "mongodb":
+		dbService = db_service.NewMongoService[ambulance_wl.Ambulance](db_service.MongoServiceConfig{})
+		visitsCollection := os.Getenv("AMBULANCE_API_MONGODB_VISITS_COLLECTION")
+		if visitsCollection == "" {
+			visitsCollection = "visits"
+		}
+		visitsDbService = db_service.NewMongoService[ambulance_wl.Visit](db_service.MongoServiceConfig{
+			Collection: visitsCollection,
+			Indexes: []db_service.IndexDefinition{
+				{Keys: []string{"ambulanceid", "waitingsince"}},
+			},
+		})
+		patientsCollection := os.Getenv("AMBULANCE_API_MONGODB_PATIENTS_COLLECTION")
+		if patientsCollection == "" {
+			patientsCollection = "patients"
+		}
+		patientsDbService = db_service.NewMongoService[ambulance_wl.Patient](db_service.MongoServiceConfig{
+			Collection: patientsCollection,
+			Indexes: []db_service.IndexDefinition{
+				{Keys: []string{"name"}},
+			},
+		})
+		conditionsCollection := os.Getenv("AMBULANCE_API_MONGODB_CONDITIONS_COLLECTION")
+		if conditionsCollection == "" {
+			conditionsCollection = "conditions"
+		}
+		catalogueDbService = db_service.NewMongoService[ambulance_wl.CatalogueCondition](db_service.MongoServiceConfig{
+			Collection: conditionsCollection,
+			Indexes: []db_service.IndexDefinition{
+				{Keys: []string{"system"}},
+				{Keys: []string{"code"}},
+			},
+		})
+	case "memory":
+		dbService = db_service.NewMemoryService[ambulance_wl.Ambulance](db_service.MemoryServiceConfig{
+			SnapshotFile: os.Getenv("AMBULANCE_API_MEMORY_SNAPSHOT"),
+		})
+		visitsDbService = db_service.NewMemoryService[ambulance_wl.Visit](db_service.MemoryServiceConfig{})
+		patientsDbService = db_service.NewMemoryService[ambulance_wl.Patient](db_service.MemoryServiceConfig{})
+		catalogueDbService = db_service.NewMemoryService[ambulance_wl.CatalogueCondition](db_service.MemoryServiceConfig{
+			SnapshotFile: os.Getenv("AMBULANCE_API_MEMORY_CATALOGUE_SNAPSHOT"),
+		})
+	default:
+		log.Fatalf("Unknown database backend: %v", backend)
+	}
+	// indexes are created at startup, the service is not ready until they are ensured, see Ping
+	for name, db := range map[string]indexedDatabase{
+		"database":                     dbService,
+		"visits database":              visitsDbService,
+		"patients database":            patientsDbService,
+		"condition catalogue database": catalogueDbService,
+	} {
+		serviceHealth.AddCheck(name, db.Ping)
+		go func() {
+			if err := db.EnsureIndexes(context.Background()); err != nil {
+				log.Printf("Failed to ensure indexes of the %v: %v", name, err)
+			}
+		}()
+	}
+	// live updates of the waiting lists, delivered to the clients connected to this process
+	waitingListEvents := ambulance_wl.NewWaitingListEvents()
 	engine.Use(func(ctx *gin.Context) {
 		ctx.Set("db_service", dbService)
+		ctx.Set("visits_db_service", visitsDbService)
+		ctx.Set("patients_db_service", patientsDbService)
+		ctx.Set("catalogue_db_service", catalogueDbService)
+		ctx.Set("waiting_list_events", waitingListEvents)
 		ctx.Next()
 	})
 
 	// request routings
 	ambulance_wl.AddRoutes(engine)
+	// waiting room screens, served without the authentication of the api
+	ambulance_wl.AddPublicRoutes(engine)
 	engine.GET("/openapi", api.HandleOpenApi)
 
+	// probes
+	engine.GET("/healthz", serviceHealth.HandleLiveness)
+	engine.GET("/readyz", serviceHealth.HandleReadiness)
+
 	// metrics endpoint
 	promhandler := promhttp.Handler()
 	engine.Any("/metrics", func(ctx *gin.Context) {
 		promhandler.ServeHTTP(ctx.Writer, ctx.Request)
 	})
-	engine.Run(":" + port)
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: engine,
+	}
+	// event streams never finish on their own, disconnect them so that the draining can complete
+	server.RegisterOnShutdown(waitingListEvents.Close)
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- server.ListenAndServe()
+	}()
+	serviceHealth.SetReady(true)
+
+	// stale entries are expired and the waiting times re-estimated in the background, zero interval disables it
+	maintenanceCtx, stopMaintenance := context.WithCancel(context.Background())
+	defer stopMaintenance()
+	maintenanceDone := make(chan struct{})
+	if interval := durationFromEnv("AMBULANCE_API_MAINTENANCE_INTERVAL_SECONDS", time.Minute); interval > 0 {
+		maintenance := ambulance_wl.NewWaitingListMaintenance(dbService, visitsDbService, waitingListEvents)
+		go func() {
+			defer close(maintenanceDone)
+			maintenance.Run(maintenanceCtx, interval)
+		}()
+	} else {
+		close(maintenanceDone)
+	}
+
+	// wait for termination
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stopSignals()
+	select {
+	case err := <-serverErrors:
+		log.Printf("Server failed: %v", err)
+	case <-signalCtx.Done():
+		log.Printf("Shutdown requested")
+	}
+	stopSignals() // second signal terminates the process immediately
+
+	// stop receiving new requests: report not ready and give the load balancer time to notice it
+	serviceHealth.SetReady(false)
+	stopMaintenance()
+	time.Sleep(durationFromEnv("AMBULANCE_API_SHUTDOWN_DELAY_SECONDS", 5*time.Second))
+
+	// drain in-flight requests
+	drainCtx, cancelDrain := context.WithTimeout(
+		context.Background(),
+		durationFromEnv("AMBULANCE_API_SHUTDOWN_TIMEOUT_SECONDS", 20*time.Second),
+	)
+	defer cancelDrain()
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Printf("Failed to drain requests: %v", err)
+	}
+	// the cancelled maintenance finishes its current database operation
+	<-maintenanceDone
+
+	// release resources in the reverse order of their initialization, the draining may have used up its timeout
+	disconnectCtx, cancelDisconnect := context.WithTimeout(
+		context.Background(),
+		durationFromEnv("AMBULANCE_API_DISCONNECT_TIMEOUT_SECONDS", 5*time.Second),
+	)
+	defer cancelDisconnect()
+	if err := catalogueDbService.Disconnect(disconnectCtx); err != nil {
+		log.Printf("Failed to disconnect from condition catalogue database: %v", err)
+	}
+	if err := patientsDbService.Disconnect(disconnectCtx); err != nil {
+		log.Printf("Failed to disconnect from patients database: %v", err)
+	}
+	if err := visitsDbService.Disconnect(disconnectCtx); err != nil {
+		log.Printf("Failed to disconnect from visits database: %v", err)
+	}
+	if err := dbService.Disconnect(disconnectCtx); err != nil {
+		log.Printf("Failed to disconnect from database: %v", err)
+	}
+	if err := shutdownTelemetry(disconnectCtx); err != nil {
+		log.Printf("Failed to shutdown telemetry: %v", err)
+	}
+	log.Printf("Server stopped")
+}
+
+// indexedDatabase is the part of the db services common to all document types
+type indexedDatabase interface {
+	EnsureIndexes(ctx context.Context) error
+	Ping(ctx context.Context) error
+}
+
+// duration in seconds from the environment variable, default value if not set or invalid
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		log.Printf("Invalid value of %v: %v", name, value)
+		return defaultValue
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // initialize OpenTelemetry instrumentations
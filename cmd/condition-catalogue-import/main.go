@@ -0,0 +1,127 @@
+// Command condition-catalogue-import loads ICD-10 or SNOMED CT style code lists from CSV files into
+// the hospital-wide condition catalogue. The database is configured by the same environment
+// variables as the ambulance-api-service; with -snapshot the catalogue is written into a JSON file
+// usable as AMBULANCE_API_MEMORY_CATALOGUE_SNAPSHOT of the memory backend instead.
+//
+//	condition-catalogue-import -system icd-10 -file icd10.csv
+//	condition-catalogue-import -system snomed-ct -delimiter tab -file sct2_Description_Snapshot-en.txt
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xlukacs/ambulance-webapi/internal/ambulance_wl"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV file with the code list, standard input if not set")
+	system := flag.String("system", "", "code system of the conditions: icd-10, snomed-ct or local")
+	delimiter := flag.String("delimiter", ",", "field delimiter, use tab for SNOMED CT release files")
+	codeColumn := flag.String("code-column", "", "name of the column with the codes")
+	valueColumn := flag.String("value-column", "", "name of the column with the descriptions")
+	referenceColumn := flag.String("reference-column", "", "name of the column with the reference links")
+	durationColumn := flag.String("duration-column", "", "name of the column with the typical durations in minutes")
+	snapshot := flag.String("snapshot", "", "write the conditions into the JSON file instead of the database")
+	flag.Parse()
+
+	format := ambulance_wl.CatalogueCsvFormat{
+		System:          *system,
+		CodeColumn:      *codeColumn,
+		ValueColumn:     *valueColumn,
+		ReferenceColumn: *referenceColumn,
+		DurationColumn:  *durationColumn,
+	}
+	switch *delimiter {
+	case "tab", `\t`:
+		format.Comma = '\t'
+	default:
+		comma, size := utf8.DecodeRuneInString(*delimiter)
+		if size == 0 || size != len(*delimiter) {
+			log.Fatalf("Delimiter must be a single character: %v", *delimiter)
+		}
+		format.Comma = comma
+	}
+
+	input := os.Stdin
+	if *file != "" {
+		var err error
+		if input, err = os.Open(*file); err != nil {
+			log.Fatalf("Failed to open the code list: %v", err)
+		}
+		defer input.Close()
+	}
+
+	conditions, err := ambulance_wl.ReadConditionCatalogue(input, format)
+	if err != nil {
+		log.Fatalf("Failed to read the code list: %v", err)
+	}
+
+	if *snapshot != "" {
+		if err := writeSnapshot(*snapshot, conditions); err != nil {
+			log.Fatalf("Failed to write the snapshot: %v", err)
+		}
+		log.Printf("Written %v conditions into %v", len(conditions), *snapshot)
+		return
+	}
+
+	collection := os.Getenv("AMBULANCE_API_MONGODB_CONDITIONS_COLLECTION")
+	if collection == "" {
+		collection = "conditions"
+	}
+	dbService := db_service.NewMongoService[ambulance_wl.CatalogueCondition](db_service.MongoServiceConfig{
+		Collection: collection,
+		Indexes: []db_service.IndexDefinition{
+			{Keys: []string{"system"}},
+			{Keys: []string{"code"}},
+		},
+	})
+	defer dbService.Disconnect(context.Background())
+
+	created, updated, err := importConditions(context.Background(), dbService, conditions)
+	log.Printf("Created %v and updated %v conditions in the catalogue", created, updated)
+	if err != nil {
+		log.Fatalf("Failed to import the conditions: %v", err)
+	}
+}
+
+// importConditions creates the conditions missing in the catalogue and replaces the existing ones
+func importConditions(
+	ctx context.Context,
+	db db_service.DbService[ambulance_wl.CatalogueCondition],
+	conditions []ambulance_wl.CatalogueCondition,
+) (created int, updated int, err error) {
+	for i := range conditions {
+		condition := &conditions[i]
+		opCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err = db.CreateDocument(opCtx, condition.Id, condition)
+		if err == db_service.ErrConflict {
+			if err = db.UpdateDocument(opCtx, condition.Id, condition); err == nil {
+				updated++
+			}
+		} else if err == nil {
+			created++
+		}
+		cancel()
+		if err != nil {
+			return created, updated, fmt.Errorf("condition %v: %w", condition.Id, err)
+		}
+	}
+	return created, updated, nil
+}
+
+// writeSnapshot stores the conditions in the format loaded by the memory backend
+func writeSnapshot(path string, conditions []ambulance_wl.CatalogueCondition) error {
+	content, err := json.MarshalIndent(conditions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
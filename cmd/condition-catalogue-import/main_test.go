@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/ambulance_wl"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type ImportSuite struct {
+	suite.Suite
+	dbService db_service.DbService[ambulance_wl.CatalogueCondition]
+}
+
+func TestImportSuite(t *testing.T) {
+	suite.Run(t, new(ImportSuite))
+}
+
+func (suite *ImportSuite) SetupTest() {
+	suite.dbService = db_service.NewMemoryService[ambulance_wl.CatalogueCondition](db_service.MemoryServiceConfig{})
+}
+
+func (suite *ImportSuite) Test_ImportConditions_CreatesAndUpdates() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.dbService.CreateDocument(ctx, "icd-10:J00", &ambulance_wl.CatalogueCondition{
+		Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Old description",
+	}))
+	conditions := []ambulance_wl.CatalogueCondition{
+		{Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis"},
+		{Id: "icd-10:J01", System: "icd-10", Code: "J01", Value: "Acute sinusitis"},
+	}
+
+	// ACT
+	created, updated, err := importConditions(ctx, suite.dbService, conditions)
+
+	// ASSERT
+	suite.Require().NoError(err)
+	suite.Equal(1, created)
+	suite.Equal(1, updated)
+	stored, err := suite.dbService.FindDocument(ctx, "icd-10:J00")
+	suite.Require().NoError(err)
+	suite.Equal("Acute nasopharyngitis", stored.Value)
+	_, err = suite.dbService.FindDocument(ctx, "icd-10:J01")
+	suite.NoError(err)
+}
+
+func (suite *ImportSuite) Test_WriteSnapshot_LoadableByMemoryService() {
+	// ARRANGE
+	path := filepath.Join(suite.T().TempDir(), "conditions.json")
+	conditions := []ambulance_wl.CatalogueCondition{
+		{Id: "local:checkup", System: "local", Code: "checkup", Value: "Kontrola", TypicalDurationMinutes: 10},
+	}
+
+	// ACT
+	err := writeSnapshot(path, conditions)
+
+	// ASSERT
+	suite.Require().NoError(err)
+	content, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	var loaded []ambulance_wl.CatalogueCondition
+	suite.Require().NoError(json.Unmarshal(content, &loaded))
+	suite.Equal(conditions, loaded)
+}
@@ -6,6 +6,7 @@ const mongoPassword = process.env.AMBULANCE_API_MONGODB_PASSWORD
 
 const database = process.env.AMBULANCE_API_MONGODB_DATABASE
 const collection = process.env.AMBULANCE_API_MONGODB_COLLECTION
+const conditionsCollection = process.env.AMBULANCE_API_MONGODB_CONDITIONS_COLLECTION || "conditions"
 
 const retrySeconds = parseInt(process.env.RETRY_CONNECTION_SECONDS || "5") || 5;
 
@@ -22,6 +23,46 @@ while(true) {
     }
 }
 
+// condition codes are validated against the catalogue, seed it with the local codes the ambulances already use
+function seedConditionCatalogue(dbInstance) {
+    if (dbInstance.getCollectionNames().includes(conditionsCollection)) {
+        print(`Collection '${conditionsCollection}' already exists in database '${database}'`)
+        return
+    }
+    dbInstance.createCollection(conditionsCollection)
+    dbInstance[conditionsCollection].createIndex({ "id": 1 }, { "unique": true })
+    dbInstance[conditionsCollection].createIndex({ "code": 1 })
+
+    // documents written by the service have lowercase field names, the sample data camel case ones
+    const field = (document, name) => document[name] ?? document[name.toLowerCase()]
+    const conditions = {}
+    dbInstance[collection].find().forEach(ambulance => {
+        const used = [
+            ...(field(ambulance, "predefinedConditions") || []),
+            ...(field(ambulance, "waitingList") || []).map(entry => entry.condition).filter(condition => condition),
+        ]
+        for (const condition of used) {
+            const code = condition.code
+            if (!code || conditions[code] || !condition.value) {
+                continue
+            }
+            conditions[code] = {
+                "id": `local:${code}`,
+                "system": "local",
+                "code": code,
+                "value": condition.value,
+                "reference": condition.reference,
+                "typicaldurationminutes": field(condition, "typicalDurationMinutes"),
+            }
+        }
+    })
+    const seed = Object.values(conditions)
+    if (seed.length > 0) {
+        dbInstance[conditionsCollection].insertMany(seed)
+    }
+    print(`Seeded ${seed.length} local conditions into '${conditionsCollection}'`)
+}
+
 // if database and collection exists, exit with success - already initialized
 const databases = connection.getDBNames()
 if (databases.includes(database)) {
@@ -29,6 +70,7 @@ if (databases.includes(database)) {
     collections = dbInstance.getCollectionNames()
     if (collections.includes(collection)) {
        print(`Collection '${collection}' already exists in database '${database}'`)
+        seedConditionCatalogue(dbInstance)
         process.exit(0);
     }
 }
@@ -39,7 +81,7 @@ const db = connection.getDB(database)
 db.createCollection(collection)
 
 // create indexes
-db[collection].createIndex({ "id": 1 })
+db[collection].createIndex({ "id": 1 }, { "unique": true })
 
 //insert sample data
 let result = db[collection].insertMany([
@@ -48,8 +90,8 @@ let result = db[collection].insertMany([
         "name": "Dr.Bobulová",
         "roomNumber": "123",
         "predefinedConditions": [
-            { "value": "Nádcha", "code": "rhinitis" },
-            { "value": "Kontrola", "code": "checkup" }
+            { "value": "Nádcha", "code": "rhinitis", "typicalDurationMinutes": 15 },
+            { "value": "Kontrola", "code": "checkup", "typicalDurationMinutes": 10 }
         ]
     }
 ]);
@@ -59,5 +101,7 @@ if (result.writeError) {
     print(`Error when writing the data: ${result.errmsg}`)
 }
 
+seedConditionCatalogue(db)
+
 // exit with success
 process.exit(0);
\ No newline at end of file
@@ -21,9 +21,18 @@ type AmbulanceConditionsAPI interface {
    // internal registration of api routes
    addRoutes(routerGroup *gin.RouterGroup)
 
+    // CreateCondition - Adds new predefined condition to the ambulance
+   CreateCondition(ctx *gin.Context)
+
+    // DeleteCondition - Deletes predefined condition of the ambulance
+   DeleteCondition(ctx *gin.Context)
+
     // GetConditions - Provides the list of conditions associated with ambulance
    GetConditions(ctx *gin.Context)
 
+    // UpdateCondition - Updates predefined condition of the ambulance
+   UpdateCondition(ctx *gin.Context)
+
 }
 
 // partial implementation of AmbulanceConditionsAPI - all functions must be implemented in add on files
@@ -36,14 +45,32 @@ func newAmbulanceConditionsAPI() AmbulanceConditionsAPI {
 }
 
 func (this *implAmbulanceConditionsAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/condition", this.CreateCondition)
+  routerGroup.Handle( http.MethodDelete, "/waiting-list/:ambulanceId/condition/:conditionCode", this.DeleteCondition)
   routerGroup.Handle( http.MethodGet, "/waiting-list/:ambulanceId/condition", this.GetConditions)
+  routerGroup.Handle( http.MethodPut, "/waiting-list/:ambulanceId/condition/:conditionCode", this.UpdateCondition)
 }
 
 
 // Copy following section to separate file, uncomment, and implement accordingly
+// // CreateCondition - Adds new predefined condition to the ambulance
+// func (this *implAmbulanceConditionsAPI) CreateCondition(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // DeleteCondition - Deletes predefined condition of the ambulance
+// func (this *implAmbulanceConditionsAPI) DeleteCondition(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
 // // GetConditions - Provides the list of conditions associated with ambulance
 // func (this *implAmbulanceConditionsAPI) GetConditions(ctx *gin.Context) {
 //  	ctx.AbortWithStatus(http.StatusNotImplemented)
 // }
 //
+// // UpdateCondition - Updates predefined condition of the ambulance
+// func (this *implAmbulanceConditionsAPI) UpdateCondition(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
 
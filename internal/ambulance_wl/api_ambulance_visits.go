@@ -0,0 +1,58 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+ package ambulance_wl
+
+import (
+   "net/http"
+
+   "github.com/gin-gonic/gin"
+)
+
+type AmbulanceVisitsAPI interface {
+
+   // internal registration of api routes
+   addRoutes(routerGroup *gin.RouterGroup)
+
+    // GetDurationStatistics - Provides the configured and learned visit durations of the ambulance
+   GetDurationStatistics(ctx *gin.Context)
+
+    // GetVisits - Provides the archive of the finished visits of the ambulance
+   GetVisits(ctx *gin.Context)
+
+}
+
+// partial implementation of AmbulanceVisitsAPI - all functions must be implemented in add on files
+type implAmbulanceVisitsAPI struct {
+
+}
+
+func newAmbulanceVisitsAPI() AmbulanceVisitsAPI {
+  return &implAmbulanceVisitsAPI{}
+}
+
+func (this *implAmbulanceVisitsAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodGet, "/ambulance/:ambulanceId/durations", this.GetDurationStatistics)
+  routerGroup.Handle( http.MethodGet, "/ambulance/:ambulanceId/visits", this.GetVisits)
+}
+
+
+// Copy following section to separate file, uncomment, and implement accordingly
+// // GetDurationStatistics - Provides the configured and learned visit durations of the ambulance
+// func (this *implAmbulanceVisitsAPI) GetDurationStatistics(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetVisits - Provides the archive of the finished visits of the ambulance
+// func (this *implAmbulanceVisitsAPI) GetVisits(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+
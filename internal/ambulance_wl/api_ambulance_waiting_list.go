@@ -21,6 +21,15 @@ type AmbulanceWaitingListAPI interface {
    // internal registration of api routes
    addRoutes(routerGroup *gin.RouterGroup)
 
+    // CallNextWaitingListEntry - Calls the next waiting patient into the ambulance
+   CallNextWaitingListEntry(ctx *gin.Context)
+
+    // CallWaitingListEntry - Calls the patient into the ambulance
+   CallWaitingListEntry(ctx *gin.Context)
+
+    // CompleteWaitingListEntry - Completes the treatment of the patient
+   CompleteWaitingListEntry(ctx *gin.Context)
+
     // CreateWaitingListEntry - Saves new entry into waiting list
    CreateWaitingListEntry(ctx *gin.Context)
 
@@ -33,6 +42,24 @@ type AmbulanceWaitingListAPI interface {
     // GetWaitingListEntry - Provides details about waiting list entry
    GetWaitingListEntry(ctx *gin.Context)
 
+    // GetWaitingListEntryByTicket - Provides the waiting list entry with the ticket number
+   GetWaitingListEntryByTicket(ctx *gin.Context)
+
+    // GetWaitingListEvents - Streams the changes of the ambulance waiting list
+   GetWaitingListEvents(ctx *gin.Context)
+
+    // MarkWaitingListEntryNoShow - Marks the patient as not showing up
+   MarkWaitingListEntryNoShow(ctx *gin.Context)
+
+    // MoveWaitingListEntry - Moves the entry to another position in the waiting list
+   MoveWaitingListEntry(ctx *gin.Context)
+
+    // StartWaitingListEntry - Starts the treatment of the patient
+   StartWaitingListEntry(ctx *gin.Context)
+
+    // TransferWaitingListEntry - Transfers the waiting patient to another ambulance
+   TransferWaitingListEntry(ctx *gin.Context)
+
     // UpdateWaitingListEntry - Updates specific entry
    UpdateWaitingListEntry(ctx *gin.Context)
 
@@ -48,15 +75,39 @@ func newAmbulanceWaitingListAPI() AmbulanceWaitingListAPI {
 }
 
 func (this *implAmbulanceWaitingListAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/next", this.CallNextWaitingListEntry)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/call", this.CallWaitingListEntry)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/complete", this.CompleteWaitingListEntry)
   routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries", this.CreateWaitingListEntry)
   routerGroup.Handle( http.MethodDelete, "/waiting-list/:ambulanceId/entries/:entryId", this.DeleteWaitingListEntry)
   routerGroup.Handle( http.MethodGet, "/waiting-list/:ambulanceId/entries", this.GetWaitingListEntries)
   routerGroup.Handle( http.MethodGet, "/waiting-list/:ambulanceId/entries/:entryId", this.GetWaitingListEntry)
+  routerGroup.Handle( http.MethodGet, "/waiting-list/:ambulanceId/tickets/:ticketNumber", this.GetWaitingListEntryByTicket)
+  routerGroup.Handle( http.MethodGet, "/waiting-list/:ambulanceId/events", this.GetWaitingListEvents)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/no-show", this.MarkWaitingListEntryNoShow)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/move", this.MoveWaitingListEntry)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/start", this.StartWaitingListEntry)
+  routerGroup.Handle( http.MethodPost, "/waiting-list/:ambulanceId/entries/:entryId/transfer", this.TransferWaitingListEntry)
   routerGroup.Handle( http.MethodPut, "/waiting-list/:ambulanceId/entries/:entryId", this.UpdateWaitingListEntry)
 }
 
 
 // Copy following section to separate file, uncomment, and implement accordingly
+// // CallNextWaitingListEntry - Calls the next waiting patient into the ambulance
+// func (this *implAmbulanceWaitingListAPI) CallNextWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // CallWaitingListEntry - Calls the patient into the ambulance
+// func (this *implAmbulanceWaitingListAPI) CallWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // CompleteWaitingListEntry - Completes the treatment of the patient
+// func (this *implAmbulanceWaitingListAPI) CompleteWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
 // // CreateWaitingListEntry - Saves new entry into waiting list
 // func (this *implAmbulanceWaitingListAPI) CreateWaitingListEntry(ctx *gin.Context) {
 //  	ctx.AbortWithStatus(http.StatusNotImplemented)
@@ -77,6 +128,36 @@ func (this *implAmbulanceWaitingListAPI) addRoutes(routerGroup *gin.RouterGroup)
 //  	ctx.AbortWithStatus(http.StatusNotImplemented)
 // }
 //
+// // GetWaitingListEntryByTicket - Provides the waiting list entry with the ticket number
+// func (this *implAmbulanceWaitingListAPI) GetWaitingListEntryByTicket(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetWaitingListEvents - Streams the changes of the ambulance waiting list
+// func (this *implAmbulanceWaitingListAPI) GetWaitingListEvents(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // MarkWaitingListEntryNoShow - Marks the patient as not showing up
+// func (this *implAmbulanceWaitingListAPI) MarkWaitingListEntryNoShow(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // MoveWaitingListEntry - Moves the entry to another position in the waiting list
+// func (this *implAmbulanceWaitingListAPI) MoveWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // StartWaitingListEntry - Starts the treatment of the patient
+// func (this *implAmbulanceWaitingListAPI) StartWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // TransferWaitingListEntry - Transfers the waiting patient to another ambulance
+// func (this *implAmbulanceWaitingListAPI) TransferWaitingListEntry(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
 // // UpdateWaitingListEntry - Updates specific entry
 // func (this *implAmbulanceWaitingListAPI) UpdateWaitingListEntry(ctx *gin.Context) {
 //  	ctx.AbortWithStatus(http.StatusNotImplemented)
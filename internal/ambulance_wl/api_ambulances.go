@@ -27,6 +27,21 @@ type AmbulancesAPI interface {
     // DeleteAmbulance - Deletes specific ambulance
    DeleteAmbulance(ctx *gin.Context)
 
+    // GetAmbulance - Provides details about specific ambulance
+   GetAmbulance(ctx *gin.Context)
+
+    // GetAmbulanceSettings - Provides operational settings of specific ambulance
+   GetAmbulanceSettings(ctx *gin.Context)
+
+    // GetAmbulances - Provides the list of ambulances
+   GetAmbulances(ctx *gin.Context)
+
+    // UpdateAmbulance - Updates name or room number of specific ambulance
+   UpdateAmbulance(ctx *gin.Context)
+
+    // UpdateAmbulanceSettings - Updates operational settings of specific ambulance
+   UpdateAmbulanceSettings(ctx *gin.Context)
+
 }
 
 // partial implementation of AmbulancesAPI - all functions must be implemented in add on files
@@ -41,6 +56,11 @@ func newAmbulancesAPI() AmbulancesAPI {
 func (this *implAmbulancesAPI) addRoutes(routerGroup *gin.RouterGroup) {
   routerGroup.Handle( http.MethodPost, "/ambulance", this.CreateAmbulance)
   routerGroup.Handle( http.MethodDelete, "/ambulance/:ambulanceId", this.DeleteAmbulance)
+  routerGroup.Handle( http.MethodGet, "/ambulance/:ambulanceId", this.GetAmbulance)
+  routerGroup.Handle( http.MethodGet, "/ambulance/:ambulanceId/settings", this.GetAmbulanceSettings)
+  routerGroup.Handle( http.MethodGet, "/ambulance", this.GetAmbulances)
+  routerGroup.Handle( http.MethodPatch, "/ambulance/:ambulanceId", this.UpdateAmbulance)
+  routerGroup.Handle( http.MethodPut, "/ambulance/:ambulanceId/settings", this.UpdateAmbulanceSettings)
 }
 
 
@@ -55,4 +75,29 @@ func (this *implAmbulancesAPI) addRoutes(routerGroup *gin.RouterGroup) {
 //  	ctx.AbortWithStatus(http.StatusNotImplemented)
 // }
 //
+// // GetAmbulance - Provides details about specific ambulance
+// func (this *implAmbulancesAPI) GetAmbulance(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetAmbulanceSettings - Provides operational settings of specific ambulance
+// func (this *implAmbulancesAPI) GetAmbulanceSettings(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetAmbulances - Provides the list of ambulances
+// func (this *implAmbulancesAPI) GetAmbulances(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // UpdateAmbulance - Updates name or room number of specific ambulance
+// func (this *implAmbulancesAPI) UpdateAmbulance(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // UpdateAmbulanceSettings - Updates operational settings of specific ambulance
+// func (this *implAmbulancesAPI) UpdateAmbulanceSettings(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
 
@@ -0,0 +1,58 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+ package ambulance_wl
+
+import (
+   "net/http"
+
+   "github.com/gin-gonic/gin"
+)
+
+type ConditionCatalogueAPI interface {
+
+   // internal registration of api routes
+   addRoutes(routerGroup *gin.RouterGroup)
+
+    // GetCatalogueCondition - Provides the condition of the hospital-wide catalogue
+   GetCatalogueCondition(ctx *gin.Context)
+
+    // GetCatalogueConditions - Provides the conditions of the hospital-wide catalogue
+   GetCatalogueConditions(ctx *gin.Context)
+
+}
+
+// partial implementation of ConditionCatalogueAPI - all functions must be implemented in add on files
+type implConditionCatalogueAPI struct {
+
+}
+
+func newConditionCatalogueAPI() ConditionCatalogueAPI {
+  return &implConditionCatalogueAPI{}
+}
+
+func (this *implConditionCatalogueAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodGet, "/conditions/:system/:conditionCode", this.GetCatalogueCondition)
+  routerGroup.Handle( http.MethodGet, "/conditions", this.GetCatalogueConditions)
+}
+
+
+// Copy following section to separate file, uncomment, and implement accordingly
+// // GetCatalogueCondition - Provides the condition of the hospital-wide catalogue
+// func (this *implConditionCatalogueAPI) GetCatalogueCondition(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetCatalogueConditions - Provides the conditions of the hospital-wide catalogue
+// func (this *implConditionCatalogueAPI) GetCatalogueConditions(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+
@@ -0,0 +1,112 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+ package ambulance_wl
+
+import (
+   "net/http"
+
+   "github.com/gin-gonic/gin"
+)
+
+type FhirAPI interface {
+
+   // internal registration of api routes
+   addRoutes(routerGroup *gin.RouterGroup)
+
+    // GetFhirAppointment - Provides the schedule as FHIR Appointment resource
+   GetFhirAppointment(ctx *gin.Context)
+
+    // GetFhirAppointments - Provides the schedules of the ambulance as Bundle of FHIR Appointment resources
+   GetFhirAppointments(ctx *gin.Context)
+
+    // GetFhirEncounter - Provides the waiting list entry as FHIR Encounter resource
+   GetFhirEncounter(ctx *gin.Context)
+
+    // GetFhirEncounters - Provides the waiting list entries of the ambulance as Bundle of FHIR Encounter resources
+   GetFhirEncounters(ctx *gin.Context)
+
+    // GetFhirHealthcareService - Provides the ambulance as FHIR HealthcareService resource
+   GetFhirHealthcareService(ctx *gin.Context)
+
+    // GetFhirHealthcareServices - Provides the ambulance as Bundle of FHIR HealthcareService resources
+   GetFhirHealthcareServices(ctx *gin.Context)
+
+    // GetFhirLocation - Provides the room of the ambulance as FHIR Location resource
+   GetFhirLocation(ctx *gin.Context)
+
+    // GetFhirLocations - Provides the rooms of the ambulance as Bundle of FHIR Location resources
+   GetFhirLocations(ctx *gin.Context)
+
+}
+
+// partial implementation of FhirAPI - all functions must be implemented in add on files
+type implFhirAPI struct {
+
+}
+
+func newFhirAPI() FhirAPI {
+  return &implFhirAPI{}
+}
+
+func (this *implFhirAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Appointment/:appointmentId", this.GetFhirAppointment)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Appointment", this.GetFhirAppointments)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Encounter/:encounterId", this.GetFhirEncounter)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Encounter", this.GetFhirEncounters)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/HealthcareService/:healthcareServiceId", this.GetFhirHealthcareService)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/HealthcareService", this.GetFhirHealthcareServices)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Location/:locationId", this.GetFhirLocation)
+  routerGroup.Handle( http.MethodGet, "/fhir/:ambulanceId/Location", this.GetFhirLocations)
+}
+
+
+// Copy following section to separate file, uncomment, and implement accordingly
+// // GetFhirAppointment - Provides the schedule as FHIR Appointment resource
+// func (this *implFhirAPI) GetFhirAppointment(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirAppointments - Provides the schedules of the ambulance as Bundle of FHIR Appointment resources
+// func (this *implFhirAPI) GetFhirAppointments(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirEncounter - Provides the waiting list entry as FHIR Encounter resource
+// func (this *implFhirAPI) GetFhirEncounter(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirEncounters - Provides the waiting list entries of the ambulance as Bundle of FHIR Encounter resources
+// func (this *implFhirAPI) GetFhirEncounters(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirHealthcareService - Provides the ambulance as FHIR HealthcareService resource
+// func (this *implFhirAPI) GetFhirHealthcareService(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirHealthcareServices - Provides the ambulance as Bundle of FHIR HealthcareService resources
+// func (this *implFhirAPI) GetFhirHealthcareServices(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirLocation - Provides the room of the ambulance as FHIR Location resource
+// func (this *implFhirAPI) GetFhirLocation(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetFhirLocations - Provides the rooms of the ambulance as Bundle of FHIR Location resources
+// func (this *implFhirAPI) GetFhirLocations(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+
@@ -0,0 +1,85 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+ package ambulance_wl
+
+import (
+   "net/http"
+
+   "github.com/gin-gonic/gin"
+)
+
+type PatientsAPI interface {
+
+   // internal registration of api routes
+   addRoutes(routerGroup *gin.RouterGroup)
+
+    // CreatePatient - Registers new patient
+   CreatePatient(ctx *gin.Context)
+
+    // DeletePatient - Deletes specific patient
+   DeletePatient(ctx *gin.Context)
+
+    // GetPatient - Provides details about specific patient
+   GetPatient(ctx *gin.Context)
+
+    // GetPatients - Provides the list of registered patients
+   GetPatients(ctx *gin.Context)
+
+    // UpdatePatient - Updates specific patient
+   UpdatePatient(ctx *gin.Context)
+
+}
+
+// partial implementation of PatientsAPI - all functions must be implemented in add on files
+type implPatientsAPI struct {
+
+}
+
+func newPatientsAPI() PatientsAPI {
+  return &implPatientsAPI{}
+}
+
+func (this *implPatientsAPI) addRoutes(routerGroup *gin.RouterGroup) {
+  routerGroup.Handle( http.MethodPost, "/patients", this.CreatePatient)
+  routerGroup.Handle( http.MethodDelete, "/patients/:patientId", this.DeletePatient)
+  routerGroup.Handle( http.MethodGet, "/patients/:patientId", this.GetPatient)
+  routerGroup.Handle( http.MethodGet, "/patients", this.GetPatients)
+  routerGroup.Handle( http.MethodPut, "/patients/:patientId", this.UpdatePatient)
+}
+
+
+// Copy following section to separate file, uncomment, and implement accordingly
+// // CreatePatient - Registers new patient
+// func (this *implPatientsAPI) CreatePatient(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // DeletePatient - Deletes specific patient
+// func (this *implPatientsAPI) DeletePatient(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetPatient - Provides details about specific patient
+// func (this *implPatientsAPI) GetPatient(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // GetPatients - Provides the list of registered patients
+// func (this *implPatientsAPI) GetPatients(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+// // UpdatePatient - Updates specific patient
+// func (this *implPatientsAPI) UpdatePatient(ctx *gin.Context) {
+//  	ctx.AbortWithStatus(http.StatusNotImplemented)
+// }
+//
+
@@ -1,6 +1,8 @@
 package ambulance_wl
 
 import (
+	"cmp"
+	"fmt"
 	"time"
 
 	"slices"
@@ -11,47 +13,200 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// triage levels of the Emergency Severity Index
+	mostUrgentPriority    = 1
+	leastUrgentPriority   = 5
+	defaultTriagePriority = 3
+	// waiting patient is raised by one triage level after each interval of waiting
+	priorityAgingInterval = 30 * time.Minute
+	// aging never raises the patient to this level, it is reserved to really urgent cases
+	maxAgedPriority = mostUrgentPriority + 1
+	// visit duration when neither the condition nor the ambulance settings provide one
+	fallbackVisitDurationMinutes = 15
+)
+
+// effectivePriority is the triage level of the entry raised by the time the patient is waiting,
+// or the priority of the manual override
+func (this *WaitingListEntry) effectivePriority(now time.Time) int32 {
+	if this.hasQueueOverride() {
+		return this.QueueOverride.Priority
+	}
+	priority := this.Priority
+	if priority == 0 {
+		priority = defaultTriagePriority
+	}
+	if priority <= maxAgedPriority {
+		return priority
+	}
+	if waiting := now.Sub(this.WaitingSince); waiting > 0 {
+		priority -= int32(waiting / priorityAgingInterval)
+	}
+	return max(priority, maxAgedPriority)
+}
+
 func (this *Ambulance) reconcileWaitingList(ctx context.Context) {
 	_, span := tracer.Start(ctx, "reconcileWaitingList",
 		trace.WithAttributes(attribute.String("ambulanceId", this.Id)),
 		trace.WithAttributes(attribute.String("ambulanceName", this.Name)),
 	)
 	defer span.End()
-	slices.SortFunc(this.WaitingList, func(left, right WaitingListEntry) int {
-		if left.WaitingSince.Before(right.WaitingSince) {
-			return -1
-		} else if left.WaitingSince.After(right.WaitingSince) {
-			return 1
-		} else {
+	if len(this.WaitingList) == 0 {
+		return
+	}
+
+	// active visits first, then waiting patients - more urgent first, then by the time of arrival
+	now := time.Now()
+	slices.SortStableFunc(this.WaitingList, func(left, right WaitingListEntry) int {
+		if order := cmp.Compare(statusOrder(&left), statusOrder(&right)); order != 0 {
+			return order
+		}
+		if !left.isWaiting() {
 			return 0
 		}
+		if order := cmp.Compare(left.effectivePriority(now), right.effectivePriority(now)); order != 0 {
+			return order
+		}
+		return left.queuedSince().Compare(right.queuedSince())
 	})
 
+	// the waiting patients can be served only when the active visits are over
+	busyUntil := now
+	waitingIndx := 0
+	for ; waitingIndx < len(this.WaitingList) && this.WaitingList[waitingIndx].isActive(); waitingIndx++ {
+		if until := this.WaitingList[waitingIndx].busyUntil(now); until.After(busyUntil) {
+			busyUntil = until
+		}
+	}
+	if waitingIndx >= len(this.WaitingList) || !this.WaitingList[waitingIndx].isWaiting() {
+		return
+	}
+
 	// we assume the first entry EstimatedStart is the correct one (computed before previous entry was deleted)
 	// but cannot be before current time
-	// for sake of simplicity we ignore concepts of opening hours here
-
-	if this.WaitingList[0].EstimatedStart.Before(this.WaitingList[0].WaitingSince) {
-		this.WaitingList[0].EstimatedStart = this.WaitingList[0].WaitingSince
+	head := &this.WaitingList[waitingIndx]
+	if head.EstimatedStart.Before(head.WaitingSince) {
+		head.EstimatedStart = head.WaitingSince
 	}
 
-	if this.WaitingList[0].EstimatedStart.Before(time.Now()) {
-		this.WaitingList[0].EstimatedStart = time.Now()
+	if head.EstimatedStart.Before(busyUntil) {
+		head.EstimatedStart = busyUntil
 	}
+	head.EstimatedStart = this.Settings.nextOpenTime(head.EstimatedStart, head.estimatedDuration())
 
-	nextEntryStart :=
-		this.WaitingList[0].EstimatedStart.
-			Add(time.Duration(this.WaitingList[0].EstimatedDurationMinutes) * time.Minute)
-	for _, entry := range this.WaitingList[1:] {
-		if entry.EstimatedStart.Before(nextEntryStart) {
-			entry.EstimatedStart = nextEntryStart
-		}
+	// the order may have changed, so the following entries start right after their predecessor
+	nextEntryStart := head.EstimatedStart.Add(head.estimatedDuration())
+	for i := waitingIndx + 1; i < len(this.WaitingList) && this.WaitingList[i].isWaiting(); i++ {
+		entry := &this.WaitingList[i]
+		entry.EstimatedStart = nextEntryStart
 		if entry.EstimatedStart.Before(entry.WaitingSince) {
 			entry.EstimatedStart = entry.WaitingSince
 		}
+		// visits take place only when the ambulance is open
+		entry.EstimatedStart = this.Settings.nextOpenTime(entry.EstimatedStart, entry.estimatedDuration())
 
-		nextEntryStart =
-			entry.EstimatedStart.
-				Add(time.Duration(entry.EstimatedDurationMinutes) * time.Minute)
+		nextEntryStart = entry.EstimatedStart.Add(entry.estimatedDuration())
 	}
 }
+
+// defaultVisitDuration is the typical duration of the predefined condition with the same code,
+// then the typical duration of the condition itself, e.g. taken from the catalogue, and
+// the ambulance default duration if none of them is known
+func (this *Ambulance) defaultVisitDuration(condition Condition) int32 {
+	if condition.Code != "" {
+		conditionIndx := slices.IndexFunc(this.PredefinedConditions, func(predefined Condition) bool {
+			return predefined.Code == condition.Code
+		})
+		if conditionIndx >= 0 && this.PredefinedConditions[conditionIndx].TypicalDurationMinutes > 0 {
+			return this.PredefinedConditions[conditionIndx].TypicalDurationMinutes
+		}
+	}
+	if condition.TypicalDurationMinutes > 0 {
+		return condition.TypicalDurationMinutes
+	}
+	if this.Settings.DefaultVisitDurationMinutes > 0 {
+		return this.Settings.DefaultVisitDurationMinutes
+	}
+	return fallbackVisitDurationMinutes
+}
+
+// nextTicketNumber assigns the next ticket number of the current day in the time zone of the ambulance.
+// The counter is part of the ambulance document, so the numbers are unique as long as the ambulance
+// is stored with the optimistic concurrency control.
+func (this *Ambulance) nextTicketNumber(now time.Time) string {
+	day := now.In(this.Settings.location()).Format(time.DateOnly)
+	if this.TicketCounter.Day != day {
+		this.TicketCounter = TicketCounter{Day: day}
+	}
+	this.TicketCounter.Last++
+	return fmt.Sprintf("%v%03d", this.Settings.TicketPrefix, this.TicketCounter.Last)
+}
+
+// issueTicket gives the entry the next ticket number and records the day the number belongs to
+func (this *Ambulance) issueTicket(entry *WaitingListEntry, now time.Time) {
+	entry.TicketNumber = this.nextTicketNumber(now)
+	entry.TicketDay = this.TicketCounter.Day
+}
+
+// ticketDay of the entry, the entries numbered before the days were recorded got the ticket
+// when they joined the waiting list
+func (this *Ambulance) ticketDay(entry *WaitingListEntry) string {
+	if entry.TicketDay != "" {
+		return entry.TicketDay
+	}
+	return entry.WaitingSince.In(this.Settings.location()).Format(time.DateOnly)
+}
+
+// assignMissingTickets numbers the entries created before the ticket numbers were introduced,
+// returns true if any entry got the ticket
+func (this *Ambulance) assignMissingTickets(now time.Time) bool {
+	assigned := false
+	for i := range this.WaitingList {
+		if entry := &this.WaitingList[i]; entry.TicketNumber == "" && !entry.isFinished() {
+			this.issueTicket(entry, now)
+			assigned = true
+		}
+	}
+	return assigned
+}
+
+// estimateVisitDuration prefers the duration learned from the completed visits of the condition
+// over the configured defaultVisitDuration
+func (this *Ambulance) estimateVisitDuration(condition Condition, learned map[string]*durationDistribution) int32 {
+	if minutes, ok := learned[condition.Code].learned(); ok && condition.Code != "" {
+		return minutes
+	}
+	return this.defaultVisitDuration(condition)
+}
+
+func (this *WaitingListEntry) estimatedDuration() time.Duration {
+	return time.Duration(this.EstimatedDurationMinutes) * time.Minute
+}
+
+// withEmptyArrays replaces the missing arrays by the empty ones, the database stores nil slices
+// as null and the elements cannot be pushed into them by the targeted updates
+func (this *Ambulance) withEmptyArrays() *Ambulance {
+	if this.WaitingList == nil {
+		this.WaitingList = []WaitingListEntry{}
+	}
+	if this.Rooms == nil {
+		this.Rooms = []Room{}
+	}
+	if this.PredefinedConditions == nil {
+		this.PredefinedConditions = []Condition{}
+	}
+	if this.Schedules == nil {
+		this.Schedules = []Schedule{}
+	}
+	return this
+}
+
+// GetVersion - implements db_service.Versioned
+func (this *Ambulance) GetVersion() int64 {
+	return this.Version
+}
+
+// SetVersion - implements db_service.Versioned
+func (this *Ambulance) SetVersion(version int64) {
+	this.Version = version
+}
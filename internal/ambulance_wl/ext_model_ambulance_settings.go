@@ -0,0 +1,233 @@
+package ambulance_wl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// how many days ahead are searched for the next open interval
+	openingHoursLookaheadDays = 14
+	// ticket numbers must stay short to be readable on the screens
+	maxTicketPrefixLength = 3
+	// grace period of the automatic expiry if the ambulance does not configure one
+	defaultExpiryGraceMinutes = 30
+	// age of the stale entries of the always open ambulance if it does not configure one
+	defaultStaleEntryMinutes = 12 * 60
+	// change of the estimate announced to the subscribers if the ambulance does not configure one
+	defaultEstimateShiftThresholdMinutes = 5
+)
+
+var ticketPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9-]*$`)
+
+// locations of the time zones, loading the time zone reads the zoneinfo database
+var locations sync.Map
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseClock parses HH:MM into the offset from the midnight
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func (this *TimeInterval) validate() error {
+	if _, ok := weekdays[strings.ToLower(this.Day)]; this.Day != "" && !ok {
+		return fmt.Errorf("invalid day %q", this.Day)
+	}
+	from, err := parseClock(this.From)
+	if err != nil {
+		return err
+	}
+	until, err := parseClock(this.Until)
+	if err != nil {
+		return err
+	}
+	if until <= from {
+		return fmt.Errorf("interval %v-%v ends before it starts", this.From, this.Until)
+	}
+	return nil
+}
+
+// on returns the interval on the given day, ok is false if it does not apply to that day
+func (this *TimeInterval) on(day time.Time) (from time.Time, until time.Time, ok bool) {
+	if this.Day != "" && weekdays[strings.ToLower(this.Day)] != day.Weekday() {
+		return time.Time{}, time.Time{}, false
+	}
+	fromOffset, fromErr := parseClock(this.From)
+	untilOffset, untilErr := parseClock(this.Until)
+	if fromErr != nil || untilErr != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return midnight.Add(fromOffset), midnight.Add(untilOffset), true
+}
+
+func (this *AmbulanceSettings) validate() error {
+	if _, err := time.LoadLocation(this.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q", this.TimeZone)
+	}
+	if this.DefaultVisitDurationMinutes < 0 {
+		return fmt.Errorf("default visit duration cannot be negative")
+	}
+	if this.ExpiryGraceMinutes < 0 {
+		return fmt.Errorf("expiry grace period cannot be negative")
+	}
+	if this.StaleEntryMinutes < 0 {
+		return fmt.Errorf("stale entry age cannot be negative")
+	}
+	if this.EstimateShiftThresholdMinutes < 0 {
+		return fmt.Errorf("estimate shift threshold cannot be negative")
+	}
+	if len(this.TicketPrefix) > maxTicketPrefixLength || !ticketPrefixPattern.MatchString(this.TicketPrefix) {
+		return fmt.Errorf("ticket prefix must be at most %v letters, digits, or dashes", maxTicketPrefixLength)
+	}
+	for _, interval := range append(slices.Clone(this.OpeningHours), this.Breaks...) {
+		if err := interval.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *AmbulanceSettings) location() *time.Location {
+	if location, ok := locations.Load(this.TimeZone); ok {
+		return location.(*time.Location)
+	}
+	location, err := time.LoadLocation(this.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	locations.Store(this.TimeZone, location)
+	return location
+}
+
+type openInterval struct {
+	from, until time.Time
+}
+
+// openIntervals returns the intervals of the day when patients are served - opening hours without breaks
+func (this *AmbulanceSettings) openIntervals(day time.Time) []openInterval {
+	intervals := []openInterval{}
+	for _, opening := range this.OpeningHours {
+		if from, until, ok := opening.on(day); ok {
+			intervals = append(intervals, openInterval{from, until})
+		}
+	}
+
+	for _, pause := range this.Breaks {
+		pauseFrom, pauseUntil, ok := pause.on(day)
+		if !ok {
+			continue
+		}
+		remaining := []openInterval{}
+		for _, interval := range intervals {
+			if !pauseFrom.Before(interval.until) || !pauseUntil.After(interval.from) {
+				remaining = append(remaining, interval) // no overlap
+				continue
+			}
+			if interval.from.Before(pauseFrom) {
+				remaining = append(remaining, openInterval{interval.from, pauseFrom})
+			}
+			if pauseUntil.Before(interval.until) {
+				remaining = append(remaining, openInterval{pauseUntil, interval.until})
+			}
+		}
+		intervals = remaining
+	}
+
+	slices.SortFunc(intervals, func(left, right openInterval) int {
+		return left.from.Compare(right.from)
+	})
+	return intervals
+}
+
+// nextOpenTime returns the earliest time not before the start when a visit of the given duration can take place.
+// The visit has to fit into one open interval, unless it is longer than the interval - then it starts
+// at the beginning of the interval. Ambulance without opening hours is always open.
+func (this *AmbulanceSettings) nextOpenTime(start time.Time, duration time.Duration) time.Time {
+	if len(this.OpeningHours) == 0 {
+		return start
+	}
+
+	location := this.location()
+	day := start.In(location)
+	for i := 0; i < openingHoursLookaheadDays; i++ {
+		for _, interval := range this.openIntervals(day) {
+			if !interval.until.After(start) {
+				continue
+			}
+			candidate := interval.from
+			if candidate.Before(start) {
+				candidate = start
+			}
+			if !candidate.Add(duration).After(interval.until) ||
+				(candidate.Equal(interval.from) && duration > interval.until.Sub(interval.from)) {
+				return candidate.In(start.Location())
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, location)
+	}
+	// no open interval in the near future, do not pretend we know better
+	return start
+}
+
+func (this *AmbulanceSettings) expiryGrace() time.Duration {
+	if this.ExpiryGraceMinutes == 0 {
+		return defaultExpiryGraceMinutes * time.Minute
+	}
+	return time.Duration(this.ExpiryGraceMinutes) * time.Minute
+}
+
+func (this *AmbulanceSettings) staleEntryAge() time.Duration {
+	if this.StaleEntryMinutes == 0 {
+		return defaultStaleEntryMinutes * time.Minute
+	}
+	return time.Duration(this.StaleEntryMinutes) * time.Minute
+}
+
+func (this *AmbulanceSettings) estimateShiftThreshold() time.Duration {
+	if this.EstimateShiftThresholdMinutes == 0 {
+		return defaultEstimateShiftThresholdMinutes * time.Minute
+	}
+	return time.Duration(this.EstimateShiftThresholdMinutes) * time.Minute
+}
+
+// closingTime returns when the ambulance closes after being open at the given time, or after it opens
+// next time if it is closed. Breaks are not closing. Zero if the ambulance is always open.
+func (this *AmbulanceSettings) closingTime(at time.Time) time.Time {
+	if len(this.OpeningHours) == 0 {
+		return time.Time{}
+	}
+
+	location := this.location()
+	day := at.In(location)
+	for i := 0; i < openingHoursLookaheadDays; i++ {
+		closing := time.Time{}
+		for _, opening := range this.OpeningHours {
+			if _, until, ok := opening.on(day); ok && until.After(closing) {
+				closing = until
+			}
+		}
+		if closing.After(at) {
+			return closing.In(at.Location())
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, location)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,237 @@
+package ambulance_wl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type ReconcileWaitingListSuite struct {
+	suite.Suite
+}
+
+func TestReconcileWaitingListSuite(t *testing.T) {
+	suite.Run(t, new(ReconcileWaitingListSuite))
+}
+
+func (suite *ReconcileWaitingListSuite) Test_EmptyList() {
+	ambulance := Ambulance{}
+
+	suite.NotPanics(func() { ambulance.reconcileWaitingList(context.Background()) })
+}
+
+func (suite *ReconcileWaitingListSuite) Test_OrderedByPriorityThenArrival() {
+	// ARRANGE
+	now := time.Now()
+	ambulance := Ambulance{
+		WaitingList: []WaitingListEntry{
+			{Id: "early-standard", WaitingSince: now.Add(-10 * time.Minute), Priority: 3, EstimatedDurationMinutes: 10},
+			{Id: "late-standard", WaitingSince: now.Add(-5 * time.Minute), Priority: 3, EstimatedDurationMinutes: 10},
+			{Id: "urgent", WaitingSince: now.Add(-1 * time.Minute), Priority: 2, EstimatedDurationMinutes: 20},
+		},
+	}
+
+	// ACT
+	ambulance.reconcileWaitingList(context.Background())
+
+	// ASSERT
+	suite.Equal("urgent", ambulance.WaitingList[0].Id)
+	suite.Equal("early-standard", ambulance.WaitingList[1].Id)
+	suite.Equal("late-standard", ambulance.WaitingList[2].Id)
+	suite.Equal(ambulance.WaitingList[0].EstimatedStart.Add(20*time.Minute), ambulance.WaitingList[1].EstimatedStart)
+	suite.Equal(ambulance.WaitingList[1].EstimatedStart.Add(10*time.Minute), ambulance.WaitingList[2].EstimatedStart)
+}
+
+func (suite *ReconcileWaitingListSuite) Test_LongWaitingPatientIsAged() {
+	// ARRANGE
+	now := time.Now()
+	ambulance := Ambulance{
+		WaitingList: []WaitingListEntry{
+			{Id: "new-urgent", WaitingSince: now.Add(-1 * time.Minute), Priority: 2},
+			{Id: "starving", WaitingSince: now.Add(-4 * priorityAgingInterval), Priority: 5},
+			{Id: "resuscitation", WaitingSince: now, Priority: 1},
+		},
+	}
+
+	// ACT
+	ambulance.reconcileWaitingList(context.Background())
+
+	// ASSERT
+	suite.Equal("resuscitation", ambulance.WaitingList[0].Id)
+	suite.Equal("starving", ambulance.WaitingList[1].Id)
+	suite.Equal("new-urgent", ambulance.WaitingList[2].Id)
+}
+
+func (suite *ReconcileWaitingListSuite) Test_NextOpenTime_SkipsBreaksAndClosedHours() {
+	// ARRANGE
+	settings := AmbulanceSettings{
+		TimeZone: "Europe/Bratislava",
+		OpeningHours: []TimeInterval{
+			{Day: "monday", From: "08:00", Until: "16:00"},
+			{Day: "tuesday", From: "08:00", Until: "16:00"},
+		},
+		Breaks: []TimeInterval{
+			{From: "12:00", Until: "12:30"},
+		},
+	}
+	location, _ := time.LoadLocation("Europe/Bratislava")
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2038, time.December, 20, hour, minute, 0, 0, location)
+	}
+
+	// ACT & ASSERT
+	suite.NoError(settings.validate())
+	suite.Equal(monday(9, 0), settings.nextOpenTime(monday(9, 0), 20*time.Minute))
+	suite.Equal(monday(8, 0), settings.nextOpenTime(monday(6, 0), 20*time.Minute))
+	suite.Equal(monday(12, 30), settings.nextOpenTime(monday(11, 50), 20*time.Minute))
+	suite.Equal(monday(8, 0).AddDate(0, 0, 1), settings.nextOpenTime(monday(15, 50), 20*time.Minute))
+	suite.Equal(monday(8, 0).AddDate(0, 0, 7), settings.nextOpenTime(monday(17, 0).AddDate(0, 0, 1), 20*time.Minute))
+}
+
+func (suite *ReconcileWaitingListSuite) Test_LocationLoadedOncePerTimeZone() {
+	bratislava := AmbulanceSettings{TimeZone: "Europe/Bratislava"}
+	unknown := AmbulanceSettings{TimeZone: "Nowhere/Unknown"}
+
+	suite.Equal("Europe/Bratislava", bratislava.location().String())
+	suite.Same(bratislava.location(), (&AmbulanceSettings{TimeZone: "Europe/Bratislava"}).location())
+	suite.Same(time.UTC, unknown.location())
+}
+
+func (suite *ReconcileWaitingListSuite) Test_DefaultVisitDuration() {
+	ambulance := Ambulance{
+		PredefinedConditions: []Condition{
+			{Value: "Nádcha", Code: "rhinitis", TypicalDurationMinutes: 25},
+			{Value: "Kontrola", Code: "checkup"},
+		},
+		Settings: AmbulanceSettings{DefaultVisitDurationMinutes: 12},
+	}
+
+	suite.Equal(int32(25), ambulance.defaultVisitDuration(Condition{Code: "rhinitis"}))
+	suite.Equal(int32(12), ambulance.defaultVisitDuration(Condition{Code: "checkup"}))
+	suite.Equal(int32(12), ambulance.defaultVisitDuration(Condition{Value: "Iné"}))
+	suite.Equal(int32(fallbackVisitDurationMinutes), (&Ambulance{}).defaultVisitDuration(Condition{}))
+}
+
+func (suite *ReconcileWaitingListSuite) Test_ActiveVisitsDelayWaitingPatients() {
+	// ARRANGE
+	now := time.Now()
+	ambulance := Ambulance{
+		WaitingList: []WaitingListEntry{
+			{Id: "done", Status: statusCompleted, WaitingSince: now.Add(-time.Hour), Priority: 1},
+			{Id: "waiting", WaitingSince: now.Add(-20 * time.Minute), Priority: 3, EstimatedDurationMinutes: 10},
+			{Id: "treated", Status: statusInTreatment, WaitingSince: now.Add(-30 * time.Minute), ActualStart: now.Add(-5 * time.Minute), Priority: 3, EstimatedDurationMinutes: 20},
+		},
+	}
+
+	// ACT
+	ambulance.reconcileWaitingList(context.Background())
+
+	// ASSERT
+	suite.Equal("treated", ambulance.WaitingList[0].Id)
+	suite.Equal("waiting", ambulance.WaitingList[1].Id)
+	suite.Equal("done", ambulance.WaitingList[2].Id)
+	suite.Equal(now.Add(15*time.Minute), ambulance.WaitingList[1].EstimatedStart)
+	suite.Equal(1, ambulance.waitingCount())
+}
+
+func (suite *ReconcileWaitingListSuite) Test_InvalidTransitionIsRejected() {
+	entry := WaitingListEntry{Id: "entry"}
+
+	suite.Error(entry.transition(transitionComplete, time.Now()))
+	suite.NoError(entry.transition(transitionCall, time.Now()))
+	suite.NoError(entry.transition(transitionStart, time.Now()))
+	suite.Error(entry.transition(transitionNoShow, time.Now()))
+	suite.Equal(statusInTreatment, entry.Status)
+}
+
+func (suite *ReconcileWaitingListSuite) Test_LearnedDurationPreferredWhenEnoughVisits() {
+	// ARRANGE
+	ctx := context.Background()
+	now := time.Now()
+	visits := db_service.NewMemoryService[Visit](db_service.MemoryServiceConfig{})
+	store := func(id string, code string, minutes int32, status string) {
+		visit := Visit{
+			Id: id, AmbulanceId: "test-ambulance", Status: status, Condition: Condition{Code: code},
+			WaitingSince: now.Add(-time.Hour), TreatmentMinutes: minutes,
+		}
+		suite.Require().NoError(visits.CreateDocument(ctx, id, &visit))
+	}
+	for i, minutes := range []int32{10, 12, 14, 30, 11} {
+		store(fmt.Sprint("fever-", i), "subfebrilia", minutes, statusCompleted)
+	}
+	store("no-show", "subfebrilia", 90, statusNoShow)
+	store("rare", "rhinitis", 40, statusCompleted)
+	ambulance := Ambulance{
+		Id: "test-ambulance",
+		PredefinedConditions: []Condition{
+			{Code: "subfebrilia", TypicalDurationMinutes: 20},
+			{Code: "rhinitis", TypicalDurationMinutes: 25},
+		},
+	}
+
+	// ACT
+	learned, err := learnDurations(ctx, visits, "test-ambulance", now)
+
+	// ASSERT
+	suite.Require().NoError(err)
+	suite.Equal(int32(12), ambulance.estimateVisitDuration(Condition{Code: "subfebrilia"}, learned))
+	suite.Equal(int32(30), learned["subfebrilia"].percentile(0.9))
+	suite.Equal(int32(25), ambulance.estimateVisitDuration(Condition{Code: "rhinitis"}, learned))
+}
+
+func (suite *ReconcileWaitingListSuite) Test_QueueOverrideHonoured() {
+	// ARRANGE
+	now := time.Now()
+	ambulance := Ambulance{
+		WaitingList: []WaitingListEntry{
+			{Id: "urgent", WaitingSince: now.Add(-10 * time.Minute), Priority: 2},
+			{Id: "standard", WaitingSince: now.Add(-5 * time.Minute), Priority: 3},
+			{Id: "moved", WaitingSince: now.Add(-time.Minute), Priority: 4},
+		},
+	}
+	ambulance.reconcileWaitingList(context.Background())
+
+	// ACT
+	ambulance.WaitingList[2].placeNextTo(&ambulance.WaitingList[0], false, "doctor", "breathing", now)
+	ambulance.reconcileWaitingList(context.Background())
+
+	// ASSERT
+	suite.Equal("moved", ambulance.WaitingList[0].Id)
+	suite.Equal("urgent", ambulance.WaitingList[1].Id)
+	suite.Equal("standard", ambulance.WaitingList[2].Id)
+	suite.Equal("doctor", ambulance.WaitingList[0].QueueOverride.ChangedBy)
+}
+
+func (suite *ReconcileWaitingListSuite) Test_NextWaitingEntryOfRoom() {
+	// ARRANGE
+	now := time.Date(2038, 12, 24, 10, 0, 0, 0, time.UTC)
+	ambulance := Ambulance{
+		Rooms: []Room{{Id: "r1"}, {Id: "r2"}},
+		WaitingList: []WaitingListEntry{
+			{Id: "of-r2", PatientId: "p1", WaitingSince: now.Add(-time.Hour)},
+			{Id: "anyone", PatientId: "p2", WaitingSince: now.Add(-50 * time.Minute)},
+			{Id: "of-r1", PatientId: "p3", WaitingSince: now.Add(-40 * time.Minute)},
+		},
+		Schedules: []Schedule{
+			{Id: "s1", PatientId: "p1", RoomId: "r2", Start: now, End: now.Add(15 * time.Minute)},
+			{Id: "s2", PatientId: "p3", RoomId: "r1", Start: now.Add(time.Hour), End: now.Add(75 * time.Minute)},
+			{Id: "yesterday", PatientId: "p2", RoomId: "r2", Start: now.Add(-24 * time.Hour), End: now.Add(-23 * time.Hour)},
+		},
+	}
+
+	// ACT
+	forR1 := ambulance.nextWaitingEntry(CallNextRequest{RoomId: "r1"}, now)
+	forR2 := ambulance.nextWaitingEntry(CallNextRequest{RoomId: "r2"}, now)
+	forR3 := ambulance.nextWaitingEntry(CallNextRequest{RoomId: "r3"}, now)
+	forAny := ambulance.nextWaitingEntry(CallNextRequest{}, now)
+
+	// ASSERT
+	suite.Equal("of-r1", ambulance.WaitingList[forR1].Id)
+	suite.Equal("of-r2", ambulance.WaitingList[forR2].Id)
+	suite.Equal("anyone", ambulance.WaitingList[forR3].Id)
+	suite.Equal("of-r2", ambulance.WaitingList[forAny].Id)
+}
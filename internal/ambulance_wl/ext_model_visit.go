@@ -0,0 +1,57 @@
+package ambulance_wl
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// final state of the visit deleted from the waiting list before the treatment
+	statusRemoved = "removed"
+	// final state of the visit nobody took care of until the ambulance closed
+	statusExpired = "expired"
+)
+
+// newVisit archives the entry which leaves the waiting list of the ambulance. Every visit gets a new id,
+// the retried archiving of the same visit must reuse the id of the first attempt.
+func newVisit(ambulance *Ambulance, entry *WaitingListEntry, archivedAt time.Time) *Visit {
+	visit := &Visit{
+		Id:                       uuid.NewString(),
+		EntryId:                  entry.Id,
+		AmbulanceId:              ambulance.Id,
+		PatientId:                entry.PatientId,
+		Name:                     entry.Name,
+		TicketNumber:             entry.TicketNumber,
+		Condition:                entry.Condition,
+		Priority:                 entry.Priority,
+		Status:                   entry.status(),
+		RoomId:                   entry.RoomId,
+		WaitingSince:             entry.WaitingSince,
+		EstimatedStart:           entry.EstimatedStart,
+		EstimatedDurationMinutes: entry.EstimatedDurationMinutes,
+		CalledAt:                 entry.CalledAt,
+		ActualStart:              entry.ActualStart,
+		ActualEnd:                entry.ActualEnd,
+		NoShowAt:                 entry.NoShowAt,
+		ArchivedAt:               archivedAt,
+	}
+	if !entry.isFinished() {
+		visit.Status = statusRemoved
+	}
+
+	if !visit.ActualStart.IsZero() {
+		visit.WaitingMinutes = minutesBetween(visit.WaitingSince, visit.ActualStart)
+		if !visit.EstimatedStart.IsZero() {
+			visit.StartDelayMinutes = minutesBetween(visit.EstimatedStart, visit.ActualStart)
+		}
+		if !visit.ActualEnd.IsZero() {
+			visit.TreatmentMinutes = minutesBetween(visit.ActualStart, visit.ActualEnd)
+		}
+	}
+	return visit
+}
+
+func minutesBetween(from time.Time, until time.Time) int32 {
+	return int32(until.Sub(from).Round(time.Minute) / time.Minute)
+}
@@ -0,0 +1,249 @@
+package ambulance_wl
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// lifecycle states of the patient visit
+const (
+	statusWaiting     = "waiting"
+	statusCalled      = "called"
+	statusInTreatment = "in-treatment"
+	statusCompleted   = "completed"
+	statusNoShow      = "no-show"
+)
+
+type visitTransition struct {
+	// states from which the transition is allowed
+	from []string
+	to   string
+	// records the time of the transition on the entry
+	record func(entry *WaitingListEntry, at time.Time)
+}
+
+var (
+	transitionCall = visitTransition{
+		from:   []string{statusWaiting},
+		to:     statusCalled,
+		record: func(entry *WaitingListEntry, at time.Time) { entry.CalledAt = at },
+	}
+	transitionStart = visitTransition{
+		from:   []string{statusWaiting, statusCalled},
+		to:     statusInTreatment,
+		record: func(entry *WaitingListEntry, at time.Time) { entry.ActualStart = at },
+	}
+	transitionComplete = visitTransition{
+		from:   []string{statusInTreatment},
+		to:     statusCompleted,
+		record: func(entry *WaitingListEntry, at time.Time) { entry.ActualEnd = at },
+	}
+	transitionNoShow = visitTransition{
+		from:   []string{statusWaiting, statusCalled},
+		to:     statusNoShow,
+		record: func(entry *WaitingListEntry, at time.Time) { entry.NoShowAt = at },
+	}
+)
+
+// status of the entry, entries stored before the lifecycle was introduced are waiting
+func (this *WaitingListEntry) status() string {
+	if this.Status == "" {
+		return statusWaiting
+	}
+	return this.Status
+}
+
+func (this *WaitingListEntry) isWaiting() bool {
+	return this.status() == statusWaiting
+}
+
+// isActive - the patient is being called or treated, the ambulance is busy with them
+func (this *WaitingListEntry) isActive() bool {
+	return this.status() == statusCalled || this.status() == statusInTreatment
+}
+
+// isFinished - the visit is over, the entry is kept only for the record
+func (this *WaitingListEntry) isFinished() bool {
+	return this.status() == statusCompleted || this.status() == statusNoShow
+}
+
+// transition moves the entry to the next state of the visit lifecycle
+func (this *WaitingListEntry) transition(transition visitTransition, at time.Time) error {
+	if !slices.Contains(transition.from, this.status()) {
+		return fmt.Errorf("cannot change status of the entry from %v to %v", this.status(), transition.to)
+	}
+	this.Status = transition.to
+	transition.record(this, at)
+	return nil
+}
+
+// queuedSince is the time determining the position of the entry among the entries with the same priority
+func (this *WaitingListEntry) queuedSince() time.Time {
+	if this.hasQueueOverride() {
+		return this.QueueOverride.QueuedSince
+	}
+	if this.QueuedSince.IsZero() {
+		return this.WaitingSince
+	}
+	return this.QueuedSince
+}
+
+func (this *WaitingListEntry) hasQueueOverride() bool {
+	return this.QueueOverride.Priority != 0
+}
+
+// placeNextTo overrides the position of the entry so that it is ordered right before or after the anchor entry.
+// The anchor keeps its position, so the order is kept unless the anchor is raised by the aging.
+func (this *WaitingListEntry) placeNextTo(anchor *WaitingListEntry, after bool, changedBy string, reason string, now time.Time) {
+	// the stored timestamps have millisecond precision
+	offset := -time.Millisecond
+	if after {
+		offset = time.Millisecond
+	}
+	this.QueueOverride = QueueOverride{
+		Priority:    anchor.effectivePriority(now),
+		QueuedSince: anchor.queuedSince().Add(offset),
+		ChangedBy:   changedBy,
+		Reason:      reason,
+		ChangedAt:   now,
+	}
+}
+
+// transferable - only patients not being treated yet can be sent to another ambulance
+func (this *WaitingListEntry) transferable() bool {
+	return this.isWaiting() || this.status() == statusCalled
+}
+
+// busyUntil estimates when the ambulance finishes the visit of the active entry
+func (this *WaitingListEntry) busyUntil(now time.Time) time.Time {
+	start := this.ActualStart
+	if start.IsZero() {
+		start = this.CalledAt
+	}
+	if start.Before(now) && this.status() == statusCalled {
+		// the patient is on the way, the treatment starts when they arrive
+		start = now
+	}
+	return start.Add(this.estimatedDuration())
+}
+
+// nextWaitingEntry returns the index of the first waiting entry of the reconciled waiting list
+// matching the constraints of the calling room, or -1 if there is no such entry. The patients
+// assigned or scheduled to the calling room go first, then the patients without any room;
+// the patients of the other rooms are left for them.
+func (this *Ambulance) nextWaitingEntry(request CallNextRequest, now time.Time) int {
+	matches := func(entry *WaitingListEntry) bool {
+		return entry.isWaiting() &&
+			(len(request.ConditionCodes) == 0 || slices.Contains(request.ConditionCodes, entry.Condition.Code))
+	}
+	if request.RoomId == "" {
+		return slices.IndexFunc(this.WaitingList, func(entry WaitingListEntry) bool {
+			return matches(&entry)
+		})
+	}
+
+	scheduled := this.scheduledRooms(now)
+	roomOf := func(entry *WaitingListEntry) string {
+		if entry.RoomId != "" {
+			return entry.RoomId
+		}
+		return scheduled[entry.PatientId]
+	}
+	if indx := slices.IndexFunc(this.WaitingList, func(entry WaitingListEntry) bool {
+		return matches(&entry) && roomOf(&entry) == request.RoomId
+	}); indx >= 0 {
+		return indx
+	}
+	return slices.IndexFunc(this.WaitingList, func(entry WaitingListEntry) bool {
+		return matches(&entry) && roomOf(&entry) == ""
+	})
+}
+
+// scheduledRooms maps the patients to the rooms of their schedules of the current day in the time
+// zone of the ambulance which are not over yet, the earliest schedule of the patient wins
+func (this *Ambulance) scheduledRooms(now time.Time) map[string]string {
+	location := this.Settings.location()
+	today := now.In(location).Format(time.DateOnly)
+	rooms := map[string]string{}
+	starts := map[string]time.Time{}
+	for _, schedule := range this.Schedules {
+		if schedule.PatientId == "" || schedule.RoomId == "" || schedule.End.Before(now) ||
+			schedule.Start.In(location).Format(time.DateOnly) != today {
+			continue
+		}
+		if start, ok := starts[schedule.PatientId]; ok && !schedule.Start.Before(start) {
+			continue
+		}
+		rooms[schedule.PatientId] = schedule.RoomId
+		starts[schedule.PatientId] = schedule.Start
+	}
+	return rooms
+}
+
+// waitingCount - number of patients still waiting for the visit
+func (this *Ambulance) waitingCount() int {
+	count := 0
+	for i := range this.WaitingList {
+		if this.WaitingList[i].isWaiting() {
+			count++
+		}
+	}
+	return count
+}
+
+// statusOrder - active entries first, then the waiting ones, finished entries at the end
+func statusOrder(entry *WaitingListEntry) int {
+	switch {
+	case entry.isActive():
+		return 0
+	case entry.isWaiting():
+		return 1
+	default:
+		return 2
+	}
+}
+
+// expireEntries removes the entries nobody took care of from the waiting list and returns their visits
+// to be archived. The called patients not showing up are marked as no-show, the entries still waiting or
+// in treatment after the closing time expire, or when they get stale if the ambulance is always open.
+// Finished entries kept by the older versions are archived as well.
+func (this *Ambulance) expireEntries(now time.Time) []*Visit {
+	grace := this.Settings.expiryGrace()
+	expired := []*Visit{}
+	this.WaitingList = slices.DeleteFunc(this.WaitingList, func(entry WaitingListEntry) bool {
+		switch {
+		case entry.isFinished():
+			expired = append(expired, newVisit(this, &entry, now))
+		case entry.status() == statusCalled:
+			deadline := entry.CalledAt
+			if entry.EstimatedStart.After(deadline) {
+				deadline = entry.EstimatedStart
+			}
+			if !now.After(deadline.Add(grace)) || entry.transition(transitionNoShow, now) != nil {
+				return false
+			}
+			expired = append(expired, newVisit(this, &entry, now))
+		default:
+			deadline := this.Settings.closingTime(entry.WaitingSince)
+			if deadline.IsZero() {
+				// the always open ambulance has no closing time, the forgotten entries expire by their age
+				deadline = entry.WaitingSince
+				if entry.status() == statusInTreatment && entry.ActualStart.After(deadline) {
+					deadline = entry.ActualStart
+				}
+				deadline = deadline.Add(this.Settings.staleEntryAge())
+			} else {
+				deadline = deadline.Add(grace)
+			}
+			if !now.After(deadline) {
+				return false
+			}
+			visit := newVisit(this, &entry, now)
+			visit.Status = statusExpired
+			expired = append(expired, visit)
+		}
+		return true
+	})
+	return expired
+}
@@ -0,0 +1,46 @@
+package ambulance_wl
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+// newMemoryStore creates the in-memory database service holding the documents under their ids
+func newMemoryStore[DocType interface{}](t *testing.T, documents map[string]DocType) db_service.DbService[DocType] {
+	db := db_service.NewMemoryService[DocType](db_service.MemoryServiceConfig{})
+	for id, document := range documents {
+		require.NoError(t, db.CreateDocument(context.Background(), id, &document))
+	}
+	return db
+}
+
+// newHandlerContext prepares the gin context of the handler under test, the services
+// are set in the context under their keys and the empty body means no request body
+func newHandlerContext(
+	services map[string]interface{},
+	method string,
+	target string,
+	body string,
+	params ...gin.Param,
+) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	for key, service := range services {
+		ctx.Set(key, service)
+	}
+	ctx.Params = params
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	ctx.Request = httptest.NewRequest(method, target, reader)
+	return ctx, recorder
+}
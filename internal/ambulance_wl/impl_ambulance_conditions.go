@@ -1,21 +1,186 @@
 package ambulance_wl
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// longest typical duration of the visit accepted for the condition
+const maxTypicalDurationMinutes = 24 * 60
+
+func (this *Condition) validate() error {
+	if this.Code == "" {
+		return fmt.Errorf("condition code is required")
+	}
+	if this.Value == "" {
+		return fmt.Errorf("condition value is required")
+	}
+	if this.TypicalDurationMinutes < 0 || this.TypicalDurationMinutes > maxTypicalDurationMinutes {
+		return fmt.Errorf("typical duration must be between 0 and %v minutes", maxTypicalDurationMinutes)
+	}
+	if this.Reference != "" {
+		reference, err := url.ParseRequestURI(this.Reference)
+		if err != nil || (reference.Scheme != "http" && reference.Scheme != "https") || reference.Host == "" {
+			return fmt.Errorf("reference must be an absolute http or https URL")
+		}
+	}
+	return nil
+}
+
 // Nasledujúci kód je kópiou vygenerovaného a zakomentovaného kódu zo súboru api_ambulance_conditions.go
+
+// CreateCondition - Adds new predefined condition to the ambulance
+func (this *implAmbulanceConditionsAPI) CreateCondition(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		var condition Condition
+
+		if err := c.ShouldBindJSON(&condition); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if condition.Code == "" {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Condition code is required",
+			}, http.StatusBadRequest
+		}
+
+		// the ambulance stores only its overrides of the catalogue condition
+		catalogue, response, status := catalogueCondition(c, condition)
+		if response != nil {
+			return nil, response, status
+		}
+		resolved := condition
+		resolved.inherit(catalogue)
+		if err := resolved.validate(); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid condition",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if slices.ContainsFunc(ambulance.PredefinedConditions, func(current Condition) bool {
+			return condition.Code == current.Code
+		}) {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Condition with the code already exists",
+			}, http.StatusConflict
+		}
+
+		ambulance.PredefinedConditions = append(ambulance.PredefinedConditions, condition)
+		return predefinedConditionsPatch(ambulance), resolved, http.StatusOK
+	})
+}
+
+// DeleteCondition - Deletes predefined condition of the ambulance
+func (this *implAmbulanceConditionsAPI) DeleteCondition(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		conditionCode := ctx.Param("conditionCode")
+
+		conditionIndx := slices.IndexFunc(ambulance.PredefinedConditions, func(current Condition) bool {
+			return conditionCode == current.Code
+		})
+
+		if conditionIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Condition not found",
+			}, http.StatusNotFound
+		}
+
+		force, _ := strconv.ParseBool(c.Query("force"))
+		usedBy := []string{}
+		for _, entry := range ambulance.WaitingList {
+			if entry.Condition.Code == conditionCode && !entry.isFinished() {
+				usedBy = append(usedBy, entry.Id)
+			}
+		}
+
+		if len(usedBy) > 0 && !force {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Condition is used by the waiting list entries, use force to delete it anyway",
+				"entries": usedBy,
+			}, http.StatusConflict
+		}
+
+		if len(usedBy) > 0 {
+			// the entries keep their copy of the condition
+			c.Header("Warning", fmt.Sprintf(`199 - "condition is still used by %v waiting list entries"`, len(usedBy)))
+		}
+
+		ambulance.PredefinedConditions = slices.Delete(ambulance.PredefinedConditions, conditionIndx, conditionIndx+1)
+		return predefinedConditionsPatch(ambulance), nil, http.StatusNoContent
+	})
+}
+
+// GetConditions - Provides the list of conditions associated with ambulance
 func (this *implAmbulanceConditionsAPI) GetConditions(ctx *gin.Context) {
 	updateAmbulanceFunc(ctx, func(
 		ctx *gin.Context,
 		ambulance *Ambulance,
 	) (updatedAmbulance *Ambulance, responseContent interface{}, status int) {
-		result := ambulance.PredefinedConditions
+		// properties not overridden by the ambulance are taken from the catalogue
+		result := resolvedConditions(ctx, ambulance.PredefinedConditions)
 		if result == nil {
 			result = []Condition{}
 		}
 		return nil, result, http.StatusOK
 	})
 }
+
+// UpdateCondition - Updates predefined condition of the ambulance
+func (this *implAmbulanceConditionsAPI) UpdateCondition(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		var condition Condition
+
+		if err := c.ShouldBindJSON(&condition); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		// the code identifies the condition and cannot be changed
+		condition.Code = ctx.Param("conditionCode")
+		catalogue, response, status := catalogueCondition(c, condition)
+		if response != nil {
+			return nil, response, status
+		}
+		resolved := condition
+		resolved.inherit(catalogue)
+		if err := resolved.validate(); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid condition",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		conditionIndx := slices.IndexFunc(ambulance.PredefinedConditions, func(current Condition) bool {
+			return condition.Code == current.Code
+		})
+
+		if conditionIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Condition not found",
+			}, http.StatusNotFound
+		}
+
+		ambulance.PredefinedConditions[conditionIndx] = condition
+		return predefinedConditionsPatch(ambulance), resolved, http.StatusOK
+	})
+}
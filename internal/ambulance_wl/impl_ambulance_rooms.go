@@ -1,11 +1,13 @@
 package ambulance_wl
 
 import (
+	"context"
 	"net/http"
 	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
 )
 
 func (this *implAmbulanceRoomsAPI) GetRooms(ctx *gin.Context) {
@@ -20,7 +22,7 @@ func (this *implAmbulanceRoomsAPI) GetRooms(ctx *gin.Context) {
 }
 
 func (this *implAmbulanceRoomsAPI) DeleteRoom(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (updatedAmbulance *Ambulance, responseContent interface{}, status int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		roomId := ctx.Param("roomId")
 
 		if roomId == "" {
@@ -42,14 +44,14 @@ func (this *implAmbulanceRoomsAPI) DeleteRoom(ctx *gin.Context) {
 		}
 
 		ambulance.Rooms = append(ambulance.Rooms[:roomIndx], ambulance.Rooms[roomIndx+1:]...)
-		//ambulance.reconcileWaitingList()
-		return ambulance, nil, http.StatusNoContent
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.PullArrayElement(ctx, ambulance.Id, "Rooms", roomId, ambulance.Version)
+		}, nil, http.StatusNoContent
 	})
 }
 
 func (this *implAmbulanceRoomsAPI) CreateRoom(ctx *gin.Context) {
-	// ctx.AbortWithStatus(http.StatusNotImplemented)
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		var entry Room
 
 		if err := c.ShouldBindJSON(&entry); err != nil {
@@ -111,24 +113,14 @@ func (this *implAmbulanceRoomsAPI) CreateRoom(ctx *gin.Context) {
 		}
 
 		ambulance.Rooms = append(ambulance.Rooms, entry)
-		//ambulance.reconcileWaitingList() TODO: this is not needed here, since we dont need to update other room data
-		//entry was copied by value return reconciled value from the list
-		entryIndx := slices.IndexFunc(ambulance.Rooms, func(room_entry Room) bool {
-			return entry.Id == room_entry.Id
-		})
-		// if entryIndx < 0 {
-		// 	return nil, gin.H{
-		// 		"status":  http.StatusInternalServerError,
-		// 		"message": "Failed to save entry",
-		// 	}, http.StatusInternalServerError
-		// }
-
-		return ambulance, ambulance.Rooms[entryIndx], http.StatusOK
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.PushArrayElement(ctx, ambulance.Id, "Rooms", entry.Id, entry, ambulance.Version)
+		}, entry, http.StatusOK
 	})
 }
 
 func (this *implAmbulanceRoomsAPI) UpdateRoom(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		var room Room
 
 		if err := c.ShouldBindJSON(&room); err != nil {
@@ -159,10 +151,12 @@ func (this *implAmbulanceRoomsAPI) UpdateRoom(ctx *gin.Context) {
 			}, http.StatusNotFound
 		}
 
-		if room.Id != "" {
-			ambulance.Rooms[roomIndx].Id = room.Id
-		} else {
-			ambulance.Rooms[roomIndx].Id = roomId
+		// the waiting list entries and schedules refer to the room by its id
+		if room.Id != "" && room.Id != roomId {
+			return nil, gin.H{
+				"status":  http.StatusForbidden,
+				"message": "Value of the roomId and the data id is mismatching",
+			}, http.StatusForbidden
 		}
 
 		if room.Width != "" {
@@ -181,7 +175,9 @@ func (this *implAmbulanceRoomsAPI) UpdateRoom(ctx *gin.Context) {
 			ambulance.Rooms[roomIndx].Name = room.Name
 		}
 
-		//ambulance.reconcileWaitingList()
-		return ambulance, ambulance.Rooms[roomIndx], http.StatusOK
+		updatedRoom := ambulance.Rooms[roomIndx]
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.SetArrayElement(ctx, ambulance.Id, "Rooms", roomId, updatedRoom, ambulance.Version)
+		}, updatedRoom, http.StatusOK
 	})
 }
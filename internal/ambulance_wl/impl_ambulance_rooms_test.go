@@ -0,0 +1,40 @@
+package ambulance_wl
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type AmbulanceRoomsSuite struct {
+	suite.Suite
+}
+
+func TestAmbulanceRoomsSuite(t *testing.T) {
+	suite.Run(t, new(AmbulanceRoomsSuite))
+}
+
+func (suite *AmbulanceRoomsSuite) Test_UpdateRoom_IdIsImmutable() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"rooms": {Id: "rooms", Rooms: []Room{{Id: "first", Name: "First"}, {Id: "second", Name: "Second"}}},
+	})
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db},
+		"PUT", "/rooms/rooms/room/first", `{"id": "second", "name": "Renamed"}`,
+		gin.Param{Key: "ambulanceId", Value: "rooms"},
+		gin.Param{Key: "roomId", Value: "first"},
+	)
+	sut := implAmbulanceRoomsAPI{}
+
+	// ACT
+	sut.UpdateRoom(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusForbidden, recorder.Code)
+	stored, _ := db.FindDocument(context.Background(), "rooms")
+	suite.Equal([]Room{{Id: "first", Name: "First"}, {Id: "second", Name: "Second"}}, stored.Rooms)
+}
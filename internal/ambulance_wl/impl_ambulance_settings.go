@@ -0,0 +1,43 @@
+package ambulance_wl
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAmbulanceSettings - Provides operational settings of specific ambulance
+func (this *implAmbulancesAPI) GetAmbulanceSettings(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		// return nil ambulance - no need to update it in db
+		return nil, ambulance.Settings, http.StatusOK
+	})
+}
+
+// UpdateAmbulanceSettings - Updates operational settings of specific ambulance
+func (this *implAmbulancesAPI) UpdateAmbulanceSettings(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		var settings AmbulanceSettings
+
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if err := settings.validate(); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid ambulance settings",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		ambulance.Settings = settings
+		// estimates depend on the opening hours
+		ambulance.reconcileWaitingList(c.Request.Context())
+		return ambulance, ambulance.Settings, http.StatusOK
+	})
+}
@@ -0,0 +1,179 @@
+package ambulance_wl
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+const (
+	defaultVisitsPageSize = 50
+	maxVisitsPageSize     = 500
+)
+
+// visitsArchive provides the db service of the visits archive from the context
+func visitsArchive(ctx *gin.Context) (db_service.DbService[Visit], error) {
+	value, exists := ctx.Get("visits_db_service")
+	if !exists {
+		return nil, fmt.Errorf("visits_db_service not found")
+	}
+	db, ok := value.(db_service.DbService[Visit])
+	if !ok {
+		return nil, fmt.Errorf("cannot cast visits_db_service context to db_service.DbService")
+	}
+	return db, nil
+}
+
+// GetDurationStatistics - Provides the configured and learned visit durations of the ambulance
+func (this *implAmbulanceVisitsAPI) GetDurationStatistics(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		visits, err := visitsArchive(c)
+		if err != nil {
+			return nil, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Visits archive not available",
+				"error":   err.Error(),
+			}, http.StatusInternalServerError
+		}
+
+		learned, err := learnDurations(c.Request.Context(), visits, ambulance.Id, time.Now())
+		if err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadGateway,
+				"message": "Failed to load visits from database",
+				"error":   err.Error(),
+			}, http.StatusBadGateway
+		}
+
+		// predefined conditions and the conditions seen in the visits
+		ambulance.PredefinedConditions = resolvedConditions(c, ambulance.PredefinedConditions)
+		conditions := slices.Clone(ambulance.PredefinedConditions)
+		for code, distribution := range learned {
+			if !slices.ContainsFunc(conditions, func(condition Condition) bool { return condition.Code == code }) {
+				conditions = append(conditions, distribution.condition)
+			}
+		}
+		slices.SortFunc(conditions, func(left, right Condition) int {
+			return strings.Compare(left.Code, right.Code)
+		})
+
+		result := []DurationStatistics{}
+		for _, condition := range conditions {
+			if condition.Code == "" {
+				continue
+			}
+			statistics := DurationStatistics{
+				ConditionCode:     condition.Code,
+				ConditionValue:    condition.Value,
+				ConfiguredMinutes: ambulance.defaultVisitDuration(condition),
+				DefaultMinutes:    ambulance.estimateVisitDuration(condition, learned),
+			}
+			if distribution, ok := learned[condition.Code]; ok {
+				statistics.LearnedMinutes, _ = distribution.learned()
+				statistics.VisitCount = int32(len(distribution.minutes))
+				statistics.Percentile25Minutes = distribution.percentile(0.25)
+				statistics.MedianMinutes = distribution.percentile(0.5)
+				statistics.Percentile75Minutes = distribution.percentile(0.75)
+				statistics.Percentile90Minutes = distribution.percentile(0.9)
+			}
+			result = append(result, statistics)
+		}
+
+		// return nil ambulance - no need to update it in db
+		return nil, result, http.StatusOK
+	})
+}
+
+// GetVisits - Provides the archive of the finished visits of the ambulance
+func (this *implAmbulanceVisitsAPI) GetVisits(ctx *gin.Context) {
+	// get db service from context
+	value, exists := ctx.Get("visits_db_service")
+	if !exists {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "visits_db_service not found",
+				"error":   "visits_db_service not found",
+			})
+		return
+	}
+
+	db, ok := value.(db_service.DbService[Visit])
+	if !ok {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "visits_db_service context is not of type db_service.DbService",
+				"error":   "cannot cast visits_db_service context to db_service.DbService",
+			})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Query parameter offset must be a non-negative integer",
+			})
+		return
+	}
+
+	limit, err := strconv.ParseInt(ctx.DefaultQuery("limit", strconv.Itoa(defaultVisitsPageSize)), 10, 64)
+	if err != nil || limit < 1 || limit > maxVisitsPageSize {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": fmt.Sprintf("Query parameter limit must be an integer between 1 and %v", maxVisitsPageSize),
+			})
+		return
+	}
+
+	// visits are selected by the time the patient entered the waiting list
+	var waitingSince db_service.TimeRange
+	for parameter, bound := range map[string]*time.Time{"from": &waitingSince.From, "until": &waitingSince.Until} {
+		if text := ctx.Query(parameter); text != "" {
+			if *bound, err = time.Parse(time.RFC3339, text); err != nil {
+				ctx.JSON(
+					http.StatusBadRequest,
+					gin.H{
+						"status":  "Bad Request",
+						"message": fmt.Sprintf("Query parameter %v must be a RFC 3339 date-time", parameter),
+						"error":   err.Error(),
+					})
+				return
+			}
+		}
+	}
+
+	visits, total, err := db.ListDocuments(ctx, db_service.ListQuery{
+		Equals:  map[string]string{"AmbulanceId": ctx.Param("ambulanceId")},
+		Between: map[string]db_service.TimeRange{"WaitingSince": waitingSince},
+		SortBy:  "WaitingSince",
+		Offset:  offset,
+		Limit:   limit,
+	})
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load visits from database",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, visits)
+}
@@ -1,13 +1,22 @@
 package ambulance_wl
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
 	"slices"
+	"strings"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xlukacs/ambulance-webapi/internal/broadcast"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -17,7 +26,7 @@ import (
 
 // CreateWaitingListEntry - Saves new entry into waiting list
 func (this *implAmbulanceWaitingListAPI) CreateWaitingListEntry(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		// special handling for gin context
 		// we need to extract the span context and create a new context to ensure span context propagation
 		// to the updater function
@@ -49,12 +58,55 @@ func (this *implAmbulanceWaitingListAPI) CreateWaitingListEntry(ctx *gin.Context
 			}, http.StatusBadRequest
 		}
 
+		// the registry is the source of truth of the patient name
+		patient, response, status := registeredPatient(c, entry.PatientId)
+		if patient == nil {
+			return nil, response, status
+		}
+		entry.Name = patient.Name
+
+		if entry.Priority == 0 {
+			entry.Priority = defaultTriagePriority
+		} else if entry.Priority < mostUrgentPriority || entry.Priority > leastUrgentPriority {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": fmt.Sprintf("Priority must be between %v and %v", mostUrgentPriority, leastUrgentPriority),
+			}, http.StatusBadRequest
+		}
+
 		if entry.Id == "" || entry.Id == "@new" {
 			entry.Id = uuid.NewString()
 		}
 
+		if entry.Condition.Code != "" {
+			catalogue, response, status := catalogueCondition(c, entry.Condition)
+			if response != nil {
+				return nil, response, status
+			}
+			entry.Condition.inherit(catalogue)
+		}
+
+		if entry.EstimatedDurationMinutes <= 0 {
+			entry.EstimatedDurationMinutes = ambulance.estimateVisitDuration(entry.Condition, learnedDurations(c, ambulance.Id))
+		}
+
+		// new entries always start the visit lifecycle
+		entry.Status = statusWaiting
+		entry.CalledAt = time.Time{}
+		entry.RoomId = ""
+		entry.ActualStart = time.Time{}
+		entry.ActualEnd = time.Time{}
+		entry.NoShowAt = time.Time{}
+		entry.QueuedSince = time.Time{}
+		entry.TransferredFrom = ""
+		entry.TransferredAt = time.Time{}
+		entry.QueueOverride = QueueOverride{}
+		entry.TicketNumber = ""
+		entry.AnnouncedEstimatedStart = time.Time{}
+
+		// finished visits of the patient do not prevent a new one
 		conflictIndx := slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
-			return entry.Id == waiting.Id || entry.PatientId == waiting.PatientId
+			return entry.Id == waiting.Id || (entry.PatientId == waiting.PatientId && !waiting.isFinished())
 		})
 
 		if conflictIndx >= 0 {
@@ -64,6 +116,9 @@ func (this *implAmbulanceWaitingListAPI) CreateWaitingListEntry(ctx *gin.Context
 			}, http.StatusConflict
 		}
 
+		// the ticket counter is stored together with the waiting list, concurrent creations
+		// fail on the version mismatch and are retried with the next number
+		ambulance.issueTicket(&entry, time.Now())
 		ambulance.WaitingList = append(ambulance.WaitingList, entry)
 		ambulance.reconcileWaitingList(spanctx)
 		// entry was copied by value return reconciled value from the list
@@ -76,13 +131,15 @@ func (this *implAmbulanceWaitingListAPI) CreateWaitingListEntry(ctx *gin.Context
 				"message": "Failed to save entry",
 			}, http.StatusInternalServerError
 		}
-		return ambulance, ambulance.WaitingList[entryIndx], http.StatusOK
+		return ticketedWaitingListPatch(ambulance), ambulance.WaitingList[entryIndx], http.StatusOK
 	})
 }
 
 // DeleteWaitingListEntry - Deletes specific entry
 func (this *implAmbulanceWaitingListAPI) DeleteWaitingListEntry(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	// the retries archive the same visit
+	visitId := uuid.NewString()
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		// special handling for gin context
 		// we need to extract the span context and create a new context to ensure span context propagation
 		// to the updater function
@@ -117,9 +174,21 @@ func (this *implAmbulanceWaitingListAPI) DeleteWaitingListEntry(ctx *gin.Context
 			}, http.StatusNotFound
 		}
 
+		visits, err := visitsArchive(c)
+		if err != nil {
+			return nil, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Visits archive not available",
+				"error":   err.Error(),
+			}, http.StatusInternalServerError
+		}
+		// keep the record of the visit
+		visit := newVisit(ambulance, &ambulance.WaitingList[entryIndx], time.Now())
+		visit.Id = visitId
+
 		ambulance.WaitingList = append(ambulance.WaitingList[:entryIndx], ambulance.WaitingList[entryIndx+1:]...)
 		ambulance.reconcileWaitingList(spanctx)
-		return ambulance, nil, http.StatusNoContent
+		return archiveVisitPatch(visits, visit, waitingListPatch(ambulance)), nil, http.StatusNoContent
 	})
 }
 
@@ -135,6 +204,125 @@ func (this *implAmbulanceWaitingListAPI) GetWaitingListEntries(ctx *gin.Context)
 	})
 }
 
+// GetWaitingListEvents - Streams the changes of the ambulance waiting list
+func (this *implAmbulanceWaitingListAPI) GetWaitingListEvents(ctx *gin.Context) {
+	value, exists := ctx.Get("waiting_list_events")
+	if !exists {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "waiting_list_events not found",
+				"error":   "waiting_list_events not found",
+			})
+		return
+	}
+	events, ok := value.(*WaitingListEvents)
+	if !ok {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "waiting_list_events context is not of type WaitingListEvents",
+				"error":   "cannot cast waiting_list_events context to WaitingListEvents",
+			})
+		return
+	}
+
+	ambulanceId := ctx.Param("ambulanceId")
+	// EventSource sends the id of the last received event when reconnecting
+	lastEventId := ctx.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = ctx.Query("lastEventId")
+	}
+
+	// changes published after the snapshot id are delivered by the subscription
+	snapshotId := events.LastEventId()
+	missed, resumed, stream, cancel := events.Subscribe(ambulanceId, lastEventId)
+	defer cancel()
+
+	if !resumed {
+		// the missed events are not available, start with the current waiting list
+		value, _ := ctx.Get("db_service")
+		db, ok := value.(db_service.DbService[Ambulance])
+		if !ok {
+			ctx.JSON(
+				http.StatusInternalServerError,
+				gin.H{
+					"status":  "Internal Server Error",
+					"message": "db_service context is not of type db_service.DbService",
+					"error":   "cannot cast db_service context to db_service.DbService",
+				})
+			return
+		}
+		ambulance, err := db.FindDocument(ctx, ambulanceId)
+		switch err {
+		case nil:
+			missed = []broadcast.Event[WaitingListEvent]{{
+				Id: snapshotId,
+				Data: WaitingListEvent{
+					Name: waitingListEventName,
+					Data: append([]WaitingListEntry{}, ambulance.WaitingList...),
+				},
+			}}
+		case db_service.ErrNotFound:
+			ctx.JSON(
+				http.StatusNotFound,
+				gin.H{
+					"status":  "Not Found",
+					"message": "Ambulance not found",
+					"error":   err.Error(),
+				})
+			return
+		default:
+			ctx.JSON(
+				http.StatusBadGateway,
+				gin.H{
+					"status":  "Bad Gateway",
+					"message": "Failed to load ambulance from database",
+					"error":   err.Error(),
+				})
+			return
+		}
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	// disable buffering of the reverse proxies
+	ctx.Header("X-Accel-Buffering", "no")
+	send := func(event broadcast.Event[WaitingListEvent]) {
+		ctx.Render(-1, sse.Event{
+			Id:    event.Id,
+			Event: event.Data.Name,
+			Data:  event.Data.Data,
+		})
+		ctx.Writer.Flush()
+	}
+	ctx.Status(http.StatusOK)
+	for _, event := range missed {
+		send(event)
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(waitingListKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				// disconnected, the client reconnects and resumes
+				return
+			}
+			send(event)
+		case <-keepAlive.C:
+			// keeps the idle connection open through the proxies
+			fmt.Fprint(ctx.Writer, ": keep-alive\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
 // GetWaitingListEntry - Provides details about waiting list entry
 func (this *implAmbulanceWaitingListAPI) GetWaitingListEntry(ctx *gin.Context) {
 	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
@@ -163,9 +351,36 @@ func (this *implAmbulanceWaitingListAPI) GetWaitingListEntry(ctx *gin.Context) {
 	})
 }
 
+// GetWaitingListEntryByTicket - Provides the waiting list entry with the ticket number
+func (this *implAmbulanceWaitingListAPI) GetWaitingListEntryByTicket(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		ticketNumber := ctx.Param("ticketNumber")
+
+		// numbers restart every day, only the tickets issued on the current day of the ambulance are valid
+		today := time.Now().In(ambulance.Settings.location()).Format(time.DateOnly)
+		var found *WaitingListEntry
+		for i := range ambulance.WaitingList {
+			entry := &ambulance.WaitingList[i]
+			if strings.EqualFold(entry.TicketNumber, ticketNumber) && ambulance.ticketDay(entry) == today {
+				found = entry
+				break
+			}
+		}
+
+		if ticketNumber == "" || found == nil {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Entry not found",
+			}, http.StatusNotFound
+		}
+
+		return nil, *found, http.StatusOK
+	})
+}
+
 // UpdateWaitingListEntry - Updates specific entry
 func (this *implAmbulanceWaitingListAPI) UpdateWaitingListEntry(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		// special handling for gin context
 		// we need to extract the span context and create a new context to ensure span context propagation
 		// to the updater function
@@ -210,8 +425,22 @@ func (this *implAmbulanceWaitingListAPI) UpdateWaitingListEntry(ctx *gin.Context
 			}, http.StatusNotFound
 		}
 
-		if entry.PatientId != "" {
+		if entry.PatientId != "" && entry.PatientId != ambulance.WaitingList[entryIndx].PatientId {
+			if slices.ContainsFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+				return entry.PatientId == waiting.PatientId && !waiting.isFinished()
+			}) {
+				return nil, gin.H{
+					"status":  http.StatusConflict,
+					"message": "Entry of the patient already exists",
+				}, http.StatusConflict
+			}
+			// the registry is the source of truth of the patient name
+			patient, response, status := registeredPatient(c, entry.PatientId)
+			if patient == nil {
+				return nil, response, status
+			}
 			ambulance.WaitingList[entryIndx].PatientId = entry.PatientId
+			ambulance.WaitingList[entryIndx].Name = patient.Name
 		}
 
 		if entry.Id != "" {
@@ -222,11 +451,461 @@ func (this *implAmbulanceWaitingListAPI) UpdateWaitingListEntry(ctx *gin.Context
 			ambulance.WaitingList[entryIndx].WaitingSince = entry.WaitingSince
 		}
 
+		if entry.Condition.Code != "" {
+			catalogue, response, status := catalogueCondition(c, entry.Condition)
+			if response != nil {
+				return nil, response, status
+			}
+			entry.Condition.inherit(catalogue)
+		}
+
+		if entry.Condition.Code != "" || entry.Condition.Value != "" {
+			conditionChanged := entry.Condition.Code != ambulance.WaitingList[entryIndx].Condition.Code
+			ambulance.WaitingList[entryIndx].Condition = entry.Condition
+			if conditionChanged && entry.EstimatedDurationMinutes <= 0 {
+				// duration was derived from the previous condition
+				ambulance.WaitingList[entryIndx].EstimatedDurationMinutes = ambulance.estimateVisitDuration(entry.Condition, learnedDurations(c, ambulance.Id))
+			}
+		}
+
 		if entry.EstimatedDurationMinutes > 0 {
 			ambulance.WaitingList[entryIndx].EstimatedDurationMinutes = entry.EstimatedDurationMinutes
 		}
 
+		if entry.Priority != 0 {
+			if entry.Priority < mostUrgentPriority || entry.Priority > leastUrgentPriority {
+				return nil, gin.H{
+					"status":  http.StatusBadRequest,
+					"message": fmt.Sprintf("Priority must be between %v and %v", mostUrgentPriority, leastUrgentPriority),
+				}, http.StatusBadRequest
+			}
+			if ambulance.WaitingList[entryIndx].Priority != entry.Priority {
+				// re-triage supersedes the manual position
+				ambulance.WaitingList[entryIndx].QueueOverride = QueueOverride{}
+			}
+			ambulance.WaitingList[entryIndx].Priority = entry.Priority
+		}
+
+		updatedId := ambulance.WaitingList[entryIndx].Id
+		ambulance.reconcileWaitingList(spanctx)
+		// entries may be reordered by reconciliation
+		entryIndx = slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+			return updatedId == waiting.Id
+		})
+		return waitingListPatch(ambulance), ambulance.WaitingList[entryIndx], http.StatusOK
+	})
+}
+
+// CallNextWaitingListEntry - Calls the next waiting patient into the ambulance
+func (this *implAmbulanceWaitingListAPI) CallNextWaitingListEntry(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		spanctx, span := tracer.Start(
+			c.Request.Context(),
+			"CallNextWaitingListEntry",
+			trace.WithAttributes(
+				attribute.String("ambulance_id", ambulance.Id),
+				attribute.String("ambulance_name", ambulance.Name),
+			),
+		)
+		c.Request = c.Request.WithContext(spanctx)
+		defer span.End()
+
+		// the constraints are optional, empty body calls any waiting patient
+		var request CallNextRequest
+		if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if request.RoomId != "" && !slices.ContainsFunc(ambulance.Rooms, func(room Room) bool {
+			return room.Id == request.RoomId
+		}) {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Room not found",
+			}, http.StatusNotFound
+		}
+
+		// the queue order may have changed since the last update, e.g. by aging of priorities
+		ambulance.reconcileWaitingList(spanctx)
+		now := time.Now()
+		entryIndx := ambulance.nextWaitingEntry(request, now)
+		if entryIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "No waiting patient matches the request",
+			}, http.StatusNotFound
+		}
+
+		entry := &ambulance.WaitingList[entryIndx]
+		if err := entry.transition(transitionCall, now); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Invalid status transition",
+				"error":   err.Error(),
+			}, http.StatusConflict
+		}
+		entry.RoomId = request.RoomId
+		// the visit starts when the patient is called, the start transition may correct it later
+		entry.ActualStart = now
+		entryId := entry.Id
+
+		ambulance.reconcileWaitingList(spanctx)
+		entryIndx = slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+			return entryId == waiting.Id
+		})
+		return waitingListPatch(ambulance), ambulance.WaitingList[entryIndx], http.StatusOK
+	})
+}
+
+// CallWaitingListEntry - Calls the patient into the ambulance
+func (this *implAmbulanceWaitingListAPI) CallWaitingListEntry(ctx *gin.Context) {
+	transitionWaitingListEntry(ctx, transitionCall)
+}
+
+// CompleteWaitingListEntry - Completes the treatment of the patient
+func (this *implAmbulanceWaitingListAPI) CompleteWaitingListEntry(ctx *gin.Context) {
+	transitionWaitingListEntry(ctx, transitionComplete)
+}
+
+// MarkWaitingListEntryNoShow - Marks the patient as not showing up
+func (this *implAmbulanceWaitingListAPI) MarkWaitingListEntryNoShow(ctx *gin.Context) {
+	transitionWaitingListEntry(ctx, transitionNoShow)
+}
+
+// MoveWaitingListEntry - Moves the entry to another position in the waiting list
+func (this *implAmbulanceWaitingListAPI) MoveWaitingListEntry(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		spanctx, span := tracer.Start(
+			c.Request.Context(),
+			"MoveWaitingListEntry",
+			trace.WithAttributes(
+				attribute.String("ambulance_id", ambulance.Id),
+				attribute.String("ambulance_name", ambulance.Name),
+			),
+		)
+		c.Request = c.Request.WithContext(spanctx)
+		defer span.End()
+
+		var request MoveRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		anchors := 0
+		for _, provided := range []bool{request.BeforeEntryId != "", request.AfterEntryId != "", request.Position != 0} {
+			if provided {
+				anchors++
+			}
+		}
+		if anchors > 1 || request.Position < 0 {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Provide at most one of beforeEntryId, afterEntryId, and a positive position",
+			}, http.StatusBadRequest
+		}
+		if anchors == 1 && (request.ChangedBy == "" || request.Reason == "") {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Who moves the entry and why is required",
+			}, http.StatusBadRequest
+		}
+
+		entryId := ctx.Param("entryId")
+		findEntry := func(id string) int {
+			return slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+				return id == waiting.Id
+			})
+		}
+
+		// the position is relative to the current order of the queue
+		ambulance.reconcileWaitingList(spanctx)
+		entryIndx := findEntry(entryId)
+		if entryIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Entry not found",
+			}, http.StatusNotFound
+		}
+		entry := &ambulance.WaitingList[entryIndx]
+		if !entry.isWaiting() {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": fmt.Sprintf("Entry in status %v cannot be moved", entry.status()),
+			}, http.StatusConflict
+		}
+
+		anchorId, after := request.BeforeEntryId, false
+		if request.AfterEntryId != "" {
+			anchorId, after = request.AfterEntryId, true
+		}
+		if request.Position > 0 {
+			others := []string{}
+			for _, waiting := range ambulance.WaitingList {
+				if waiting.isWaiting() && waiting.Id != entryId {
+					others = append(others, waiting.Id)
+				}
+			}
+			switch {
+			case len(others) == 0:
+				anchorId = ""
+			case int(request.Position) <= len(others):
+				anchorId, after = others[request.Position-1], false
+			default:
+				anchorId, after = others[len(others)-1], true
+			}
+		}
+
+		now := time.Now()
+		switch {
+		case anchors == 0:
+			// back to the automatic ordering
+			entry.QueueOverride = QueueOverride{}
+		case anchorId == "":
+			// the only waiting entry, pin its current position
+			current := *entry
+			entry.placeNextTo(&current, false, request.ChangedBy, request.Reason, now)
+		default:
+			anchorIndx := findEntry(anchorId)
+			if anchorIndx < 0 || anchorId == entryId || !ambulance.WaitingList[anchorIndx].isWaiting() {
+				return nil, gin.H{
+					"status":  http.StatusBadRequest,
+					"message": "The entry can be moved only next to another waiting entry",
+				}, http.StatusBadRequest
+			}
+			entry.placeNextTo(&ambulance.WaitingList[anchorIndx], after, request.ChangedBy, request.Reason, now)
+		}
+
+		ambulance.reconcileWaitingList(spanctx)
+		entryIndx = findEntry(entryId)
+		return waitingListPatch(ambulance), ambulance.WaitingList[entryIndx], http.StatusOK
+	})
+}
+
+// StartWaitingListEntry - Starts the treatment of the patient
+func (this *implAmbulanceWaitingListAPI) StartWaitingListEntry(ctx *gin.Context) {
+	transitionWaitingListEntry(ctx, transitionStart)
+}
+
+// transitionWaitingListEntry moves the entry to the next state of the visit lifecycle
+func transitionWaitingListEntry(ctx *gin.Context, transition visitTransition) {
+	// the retries archive the same visit
+	visitId := uuid.NewString()
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		spanctx, span := tracer.Start(
+			c.Request.Context(),
+			"TransitionWaitingListEntry",
+			trace.WithAttributes(
+				attribute.String("ambulance_id", ambulance.Id),
+				attribute.String("ambulance_name", ambulance.Name),
+				attribute.String("status", transition.to),
+			),
+		)
+		c.Request = c.Request.WithContext(spanctx)
+		defer span.End()
+
+		entryId := ctx.Param("entryId")
+
+		if entryId == "" {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Entry ID is required",
+			}, http.StatusBadRequest
+		}
+
+		entryIndx := slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+			return entryId == waiting.Id
+		})
+
+		if entryIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Entry not found",
+			}, http.StatusNotFound
+		}
+
+		now := time.Now()
+		if err := ambulance.WaitingList[entryIndx].transition(transition, now); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Invalid status transition",
+				"error":   err.Error(),
+			}, http.StatusConflict
+		}
+
+		// finished visits leave the waiting list for the archive
+		if entry := ambulance.WaitingList[entryIndx]; entry.isFinished() {
+			visits, err := visitsArchive(c)
+			if err != nil {
+				return nil, gin.H{
+					"status":  http.StatusInternalServerError,
+					"message": "Visits archive not available",
+					"error":   err.Error(),
+				}, http.StatusInternalServerError
+			}
+			visit := newVisit(ambulance, &entry, now)
+			visit.Id = visitId
+			ambulance.WaitingList = slices.Delete(ambulance.WaitingList, entryIndx, entryIndx+1)
+			ambulance.reconcileWaitingList(spanctx)
+			return archiveVisitPatch(visits, visit, waitingListPatch(ambulance)), entry, http.StatusOK
+		}
+
+		ambulance.reconcileWaitingList(spanctx)
+		// entries may be reordered by reconciliation
+		entryIndx = slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+			return entryId == waiting.Id
+		})
+		return waitingListPatch(ambulance), ambulance.WaitingList[entryIndx], http.StatusOK
+	})
+}
+
+// TransferWaitingListEntry - Transfers the waiting patient to another ambulance
+func (this *implAmbulanceWaitingListAPI) TransferWaitingListEntry(ctx *gin.Context) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		spanctx, span := tracer.Start(
+			c.Request.Context(),
+			"TransferWaitingListEntry",
+			trace.WithAttributes(
+				attribute.String("ambulance_id", ambulance.Id),
+				attribute.String("ambulance_name", ambulance.Name),
+			),
+		)
+		c.Request = c.Request.WithContext(spanctx)
+		defer span.End()
+
+		var request TransferRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if request.TargetAmbulanceId == "" || request.TargetAmbulanceId == ambulance.Id {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Target ambulance must be different from the current one",
+			}, http.StatusBadRequest
+		}
+
+		entryId := ctx.Param("entryId")
+		entryIndx := slices.IndexFunc(ambulance.WaitingList, func(waiting WaitingListEntry) bool {
+			return entryId == waiting.Id
+		})
+
+		if entryIndx < 0 {
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Entry not found",
+			}, http.StatusNotFound
+		}
+
+		entry := ambulance.WaitingList[entryIndx]
+		if !entry.transferable() {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": fmt.Sprintf("Entry in status %v cannot be transferred", entry.status()),
+			}, http.StatusConflict
+		}
+
+		// the patient waits for the call in the target ambulance again
+		now := time.Now()
+		entry.Status = statusWaiting
+		entry.CalledAt = time.Time{}
+		entry.RoomId = ""
+		entry.ActualStart = time.Time{}
+		entry.EstimatedStart = time.Time{}
+		entry.TransferredFrom = ambulance.Id
+		entry.TransferredAt = now
+		entry.QueueOverride = QueueOverride{}
+		if request.PreservePosition {
+			entry.QueuedSince = time.Time{}
+		} else {
+			entry.QueuedSince = now
+		}
+
+		// the entry is already in the target if the previous attempt was interrupted, it keeps the ticket
+		// issued then. Other entry with the same id is unrelated and must not be replaced.
+		previousAttempt := func(waiting WaitingListEntry) bool {
+			return waiting.Id == entry.Id && waiting.TransferredFrom == ambulance.Id && waiting.PatientId == entry.PatientId
+		}
+		insertIntoTarget := func(target *Ambulance) error {
+			if slices.ContainsFunc(target.WaitingList, previousAttempt) {
+				return nil
+			}
+			if slices.ContainsFunc(target.WaitingList, func(waiting WaitingListEntry) bool {
+				return waiting.Id == entry.Id || (waiting.PatientId == entry.PatientId && !waiting.isFinished())
+			}) {
+				return db_service.ErrConflict
+			}
+			inserted := entry
+			target.issueTicket(&inserted, now)
+			inserted.AnnouncedEstimatedStart = time.Time{}
+			target.WaitingList = append(target.WaitingList, inserted)
+			return nil
+		}
+		removeFromTarget := func(target *Ambulance) error {
+			target.WaitingList = slices.DeleteFunc(target.WaitingList, previousAttempt)
+			return nil
+		}
+
+		// report the missing or conflicting target before changing anything
+		db, ok := c.Value("db_service").(db_service.DbService[Ambulance])
+		if !ok {
+			return nil, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "db_service context is not of type db_service.DbService",
+			}, http.StatusInternalServerError
+		}
+		target, err := db.FindDocument(spanctx, request.TargetAmbulanceId)
+		switch {
+		case err == db_service.ErrNotFound:
+			return nil, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Target ambulance not found",
+			}, http.StatusNotFound
+		case err != nil:
+			return nil, gin.H{
+				"status":  http.StatusBadGateway,
+				"message": "Failed to load target ambulance from database",
+				"error":   err.Error(),
+			}, http.StatusBadGateway
+		case insertIntoTarget(target) != nil:
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Patient is already waiting in the target ambulance or the entry id is used there",
+			}, http.StatusConflict
+		}
+
+		ambulance.WaitingList = slices.Delete(ambulance.WaitingList, entryIndx, entryIndx+1)
 		ambulance.reconcileWaitingList(spanctx)
-		return ambulance, ambulance.WaitingList[entryIndx], http.StatusOK
+		removeFromSource := waitingListPatch(ambulance)
+
+		// there are no transactions across the documents - the entry is first added to the target
+		// and removed from the target again if the source cannot be updated
+		transferred := &WaitingListEntry{}
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			target, err := changeWaitingList(ctx, db, request.TargetAmbulanceId, insertIntoTarget)
+			if err != nil {
+				return err
+			}
+			if err := removeFromSource(ctx, db); err != nil {
+				if _, rollbackErr := changeWaitingList(ctx, db, request.TargetAmbulanceId, removeFromTarget); rollbackErr != nil {
+					log.Printf("Failed to roll back the transfer of the entry %v to the ambulance %v: %v", entry.Id, request.TargetAmbulanceId, rollbackErr)
+				}
+				return err
+			}
+			publishWaitingList(c, target)
+			*transferred = target.WaitingList[slices.IndexFunc(target.WaitingList, previousAttempt)]
+			return nil
+		}, transferred, http.StatusOK
 	})
 }
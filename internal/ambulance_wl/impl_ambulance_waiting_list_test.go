@@ -2,8 +2,12 @@ package ambulance_wl
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -36,6 +40,11 @@ func (this *DbServiceMock[DocType]) FindDocument(ctx context.Context, id string)
 	return args.Get(0).(*DocType), args.Error(1)
 }
 
+func (this *DbServiceMock[DocType]) ListDocuments(ctx context.Context, query db_service.ListQuery) ([]*DocType, int64, error) {
+	args := this.Called(ctx, query)
+	return args.Get(0).([]*DocType), args.Get(1).(int64), args.Error(2)
+}
+
 func (this *DbServiceMock[DocType]) UpdateDocument(ctx context.Context, id string, document *DocType) error {
 	args := this.Called(ctx, id, document)
 	return args.Error(0)
@@ -46,11 +55,46 @@ func (this *DbServiceMock[DocType]) DeleteDocument(ctx context.Context, id strin
 	return args.Error(0)
 }
 
+func (this *DbServiceMock[DocType]) PushArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	args := this.Called(ctx, id, array, elementId, element, expectedVersion)
+	return args.Error(0)
+}
+
+func (this *DbServiceMock[DocType]) PullArrayElement(ctx context.Context, id string, array string, elementId string, expectedVersion int64) error {
+	args := this.Called(ctx, id, array, elementId, expectedVersion)
+	return args.Error(0)
+}
+
+func (this *DbServiceMock[DocType]) SetArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	args := this.Called(ctx, id, array, elementId, element, expectedVersion)
+	return args.Error(0)
+}
+
+func (this *DbServiceMock[DocType]) SetProperty(ctx context.Context, id string, property string, value interface{}, expectedVersion int64) error {
+	args := this.Called(ctx, id, property, value, expectedVersion)
+	return args.Error(0)
+}
+
+func (this *DbServiceMock[DocType]) SetProperties(ctx context.Context, id string, properties map[string]interface{}, expectedVersion int64) error {
+	args := this.Called(ctx, id, properties, expectedVersion)
+	return args.Error(0)
+}
+
 func (this *DbServiceMock[DocType]) Disconnect(ctx context.Context) error {
 	args := this.Called(ctx)
 	return args.Error(0)
 }
 
+func (this *DbServiceMock[DocType]) Ping(ctx context.Context) error {
+	args := this.Called(ctx)
+	return args.Error(0)
+}
+
+func (this *DbServiceMock[DocType]) EnsureIndexes(ctx context.Context) error {
+	args := this.Called(ctx)
+	return args.Error(0)
+}
+
 func (suite *AmbulanceWlSuite) SetupTest() {
 	suite.dbServiceMock = &DbServiceMock[Ambulance]{}
 
@@ -75,10 +119,20 @@ func (suite *AmbulanceWlSuite) SetupTest() {
 		)
 }
 
-func (suite *AmbulanceWlSuite) Test_UpdateWl_DbServiceUpdateCalled() {
+// archivedVisit finds the only visit of the waiting list entry in the archive
+func (suite *AmbulanceWlSuite) archivedVisit(visits db_service.DbService[Visit], entryId string) *Visit {
+	archived, _, err := visits.ListDocuments(context.Background(), db_service.ListQuery{
+		Equals: map[string]string{"EntryId": entryId},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(archived, 1)
+	return archived[0]
+}
+
+func (suite *AmbulanceWlSuite) Test_UpdateWl_DbServiceSetPropertyCalled() {
 	// ARRANGE
 	suite.dbServiceMock.
-		On("UpdateDocument", mock.Anything, mock.Anything, mock.Anything).
+		On("SetProperty", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(nil)
 
 	json := `{
@@ -103,5 +157,594 @@ func (suite *AmbulanceWlSuite) Test_UpdateWl_DbServiceUpdateCalled() {
 	sut.UpdateWaitingListEntry(ctx)
 
 	// ASSERT
-	suite.dbServiceMock.AssertCalled(suite.T(), "UpdateDocument", mock.Anything, "test-ambulance", mock.Anything)
+	suite.dbServiceMock.AssertCalled(suite.T(), "SetProperty", mock.Anything, "test-ambulance", "WaitingList", mock.Anything, mock.Anything)
+}
+
+func (suite *AmbulanceWlSuite) Test_UpdateWl_VersionMismatchRetriedThenConflict() {
+	// ARRANGE
+	suite.dbServiceMock.
+		On("SetProperty", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(db_service.ErrVersionMismatch)
+
+	json := `{
+		"id": "test-entry",
+		"patientId": "test-patient",
+		"estimatedDurationMinutes": 42
+	  }`
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Set("db_service", suite.dbServiceMock)
+	ctx.Params = []gin.Param{
+		{Key: "ambulanceId", Value: "test-ambulance"},
+		{Key: "entryId", Value: "test-entry"},
+	}
+	ctx.Request = httptest.NewRequest("POST", "/ambulance/test-ambulance/waitinglist/test-entry", strings.NewReader(json))
+
+	sut := implAmbulanceWaitingListAPI{}
+
+	// ACT
+	sut.UpdateWaitingListEntry(ctx)
+
+	// ASSERT
+	suite.dbServiceMock.AssertNumberOfCalls(suite.T(), "SetProperty", maxUpdateAttempts)
+	suite.Equal(http.StatusConflict, recorder.Code)
+}
+
+func (suite *AmbulanceWlSuite) Test_CallNext_HeadIsCalled() {
+	// ARRANGE
+	suite.dbServiceMock.
+		On("SetProperty", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Set("db_service", suite.dbServiceMock)
+	ctx.Params = []gin.Param{
+		{Key: "ambulanceId", Value: "test-ambulance"},
+	}
+	ctx.Request = httptest.NewRequest("POST", "/waiting-list/test-ambulance/next", nil)
+
+	sut := implAmbulanceWaitingListAPI{}
+
+	// ACT
+	sut.CallNextWaitingListEntry(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.Contains(recorder.Body.String(), `"status":"called"`)
+	suite.dbServiceMock.AssertCalled(suite.T(), "SetProperty", mock.Anything, "test-ambulance", "WaitingList",
+		mock.MatchedBy(func(list []WaitingListEntry) bool {
+			return len(list) == 1 && list[0].Status == statusCalled && !list[0].CalledAt.IsZero()
+		}), mock.Anything)
+}
+
+func (suite *AmbulanceWlSuite) Test_Complete_EntryArchived() {
+	// ARRANGE
+	suite.dbServiceMock.ExpectedCalls = nil
+	suite.dbServiceMock.
+		On("FindDocument", mock.Anything, mock.Anything).
+		Return(
+			&Ambulance{
+				Id: "test-ambulance",
+				WaitingList: []WaitingListEntry{
+					{
+						Id:           "test-entry",
+						PatientId:    "test-patient",
+						Status:       statusInTreatment,
+						WaitingSince: time.Now().Add(-time.Hour),
+						ActualStart:  time.Now().Add(-20 * time.Minute),
+					},
+				},
+			},
+			nil,
+		)
+	suite.dbServiceMock.
+		On("SetProperty", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	visits := db_service.NewMemoryService[Visit](db_service.MemoryServiceConfig{})
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Set("db_service", suite.dbServiceMock)
+	ctx.Set("visits_db_service", visits)
+	ctx.Params = []gin.Param{
+		{Key: "ambulanceId", Value: "test-ambulance"},
+		{Key: "entryId", Value: "test-entry"},
+	}
+	ctx.Request = httptest.NewRequest("POST", "/waiting-list/test-ambulance/entries/test-entry/complete", nil)
+
+	sut := implAmbulanceWaitingListAPI{}
+
+	// ACT
+	sut.CompleteWaitingListEntry(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.dbServiceMock.AssertCalled(suite.T(), "SetProperty", mock.Anything, "test-ambulance", "WaitingList",
+		[]WaitingListEntry{}, mock.Anything)
+	visit := suite.archivedVisit(visits, "test-entry")
+	suite.Equal(statusCompleted, visit.Status)
+	suite.Equal("test-ambulance", visit.AmbulanceId)
+	suite.Equal(int32(40), visit.WaitingMinutes)
+	suite.Equal(int32(20), visit.TreatmentMinutes)
+}
+
+func (suite *AmbulanceWlSuite) Test_Complete_ReusedEntryIdKeepsHistory() {
+	// ARRANGE
+	db := db_service.NewMemoryService[Ambulance](db_service.MemoryServiceConfig{})
+	visits := db_service.NewMemoryService[Visit](db_service.MemoryServiceConfig{})
+	treated := func(actualStart time.Time) *Ambulance {
+		return &Ambulance{
+			Id: "reused",
+			WaitingList: []WaitingListEntry{{
+				Id: "entry", PatientId: "p1", Status: statusInTreatment,
+				WaitingSince: actualStart.Add(-time.Hour), ActualStart: actualStart,
+			}},
+		}
+	}
+	suite.Require().NoError(db.CreateDocument(context.Background(), "reused", treated(time.Now().Add(-3*time.Hour))))
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceWaitingListAPI{}
+	complete := func() int {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", db)
+		ctx.Set("visits_db_service", visits)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "reused"}, {Key: "entryId", Value: "entry"}}
+		ctx.Request = httptest.NewRequest("POST", "/waiting-list/reused/entries/entry/complete", nil)
+		sut.CompleteWaitingListEntry(ctx)
+		return recorder.Code
+	}
+
+	// ACT
+	first := complete()
+	stored, err := db.FindDocument(context.Background(), "reused")
+	suite.Require().NoError(err)
+	again := treated(time.Now().Add(-time.Hour))
+	again.Version = stored.Version
+	suite.Require().NoError(db.UpdateDocument(context.Background(), "reused", again))
+	second := complete()
+
+	// ASSERT
+	suite.Equal(http.StatusOK, first)
+	suite.Equal(http.StatusOK, second)
+	archived, total, err := visits.ListDocuments(context.Background(), db_service.ListQuery{
+		Equals: map[string]string{"EntryId": "entry"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), total)
+	suite.NotEqual(archived[0].Id, archived[1].Id)
+}
+
+// streamRecorder records the streamed response, which is read while the handler still writes it
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	lock sync.Mutex
+}
+
+func (this *streamRecorder) Write(content []byte) (int, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.ResponseRecorder.Write(content)
+}
+
+func (this *streamRecorder) Flush() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.ResponseRecorder.Flush()
+}
+
+func (this *streamRecorder) body() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.ResponseRecorder.Body.String()
+}
+
+// streamEvents runs the events handler until the returned function is called, which waits for the handler to return
+func (suite *AmbulanceWlSuite) streamEvents(services map[string]interface{}, lastEventId string) (*streamRecorder, func()) {
+	gin.SetMode(gin.TestMode)
+	recorder := &streamRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx, _ := gin.CreateTestContext(recorder)
+	for key, service := range services {
+		ctx.Set(key, service)
+	}
+	ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "events"}}
+	requestCtx, cancel := context.WithCancel(context.Background())
+	ctx.Request = httptest.NewRequest("GET", "/waiting-list/events/events", nil).WithContext(requestCtx)
+	if lastEventId != "" {
+		ctx.Request.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sut := implAmbulanceWaitingListAPI{}
+		sut.GetWaitingListEvents(ctx)
+	}()
+	return recorder, func() {
+		cancel()
+		<-done
+	}
+}
+
+func (suite *AmbulanceWlSuite) Test_Events_SnapshotChangesAndKeepAlive() {
+	// ARRANGE
+	defer func(interval time.Duration) { waitingListKeepAliveInterval = interval }(waitingListKeepAliveInterval)
+	waitingListKeepAliveInterval = 10 * time.Millisecond
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"events": {Id: "events", WaitingList: []WaitingListEntry{
+			{Id: "stored-entry", PatientId: "stored-patient", WaitingSince: time.Now()},
+		}},
+	})
+	events := NewWaitingListEvents()
+	contains := func(recorder *streamRecorder, content string) func() bool {
+		return func() bool { return strings.Contains(recorder.body(), content) }
+	}
+
+	// ACT
+	recorder, stop := suite.streamEvents(map[string]interface{}{"db_service": db, "waiting_list_events": events}, "")
+	suite.Eventually(contains(recorder, "stored-entry"), time.Second, time.Millisecond)
+	publishWaitingListTo(events, &Ambulance{Id: "events", WaitingList: []WaitingListEntry{{Id: "published-entry"}}})
+	suite.Eventually(contains(recorder, "published-entry"), time.Second, time.Millisecond)
+	suite.Eventually(contains(recorder, ": keep-alive"), time.Second, time.Millisecond)
+	stop()
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.Equal("text/event-stream", recorder.Header().Get("Content-Type"))
+	body := recorder.body()
+	suite.Less(strings.Index(body, "stored-entry"), strings.Index(body, "published-entry"))
+	suite.Contains(body, "event:"+waitingListEventName)
+	suite.Contains(body, "id:"+events.LastEventId())
+}
+
+func (suite *AmbulanceWlSuite) Test_Events_ResumedAfterLastEventId() {
+	// ARRANGE
+	events := NewWaitingListEvents()
+	publishWaitingListTo(events, &Ambulance{Id: "events", WaitingList: []WaitingListEntry{{Id: "received-entry"}}})
+	lastEventId := events.LastEventId()
+	publishWaitingListTo(events, &Ambulance{Id: "events", WaitingList: []WaitingListEntry{{Id: "missed-entry"}}})
+
+	// ACT
+	// no database, the resumed stream does not start with the snapshot
+	recorder, stop := suite.streamEvents(map[string]interface{}{"waiting_list_events": events}, lastEventId)
+	suite.Eventually(func() bool { return strings.Contains(recorder.body(), "missed-entry") }, time.Second, time.Millisecond)
+	stop()
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.NotContains(recorder.body(), "received-entry")
+	suite.Contains(recorder.body(), "id:"+events.LastEventId())
+}
+
+// moveAmbulance has the waiting entries first, second and third in this order and the called one
+func (suite *AmbulanceWlSuite) moveAmbulance() db_service.DbService[Ambulance] {
+	now := time.Now()
+	return newMemoryStore(suite.T(), map[string]Ambulance{
+		"move": {Id: "move", WaitingList: []WaitingListEntry{
+			{Id: "first", PatientId: "first-patient", WaitingSince: now.Add(-3 * time.Hour)},
+			{Id: "second", PatientId: "second-patient", WaitingSince: now.Add(-2 * time.Hour)},
+			{Id: "third", PatientId: "third-patient", WaitingSince: now.Add(-time.Hour)},
+			{Id: "called", PatientId: "called-patient", WaitingSince: now.Add(-4 * time.Hour), Status: statusCalled},
+		}},
+	})
+}
+
+func (suite *AmbulanceWlSuite) move(db db_service.DbService[Ambulance], entryId string, body string) int {
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db},
+		"POST", "/waiting-list/move/entries/"+entryId+"/move", body,
+		gin.Param{Key: "ambulanceId", Value: "move"},
+		gin.Param{Key: "entryId", Value: entryId},
+	)
+	sut := implAmbulanceWaitingListAPI{}
+	sut.MoveWaitingListEntry(ctx)
+	return recorder.Code
+}
+
+func (suite *AmbulanceWlSuite) Test_Move_OverrideRecorded() {
+	// ARRANGE
+	db := suite.moveAmbulance()
+
+	// ACT
+	status := suite.move(db, "third", `{"beforeEntryId": "first", "changedBy": "nurse", "reason": "worsened"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, status)
+	stored, _ := db.FindDocument(context.Background(), "move")
+	waiting := []string{}
+	for _, entry := range stored.WaitingList {
+		if entry.isWaiting() {
+			waiting = append(waiting, entry.Id)
+		}
+	}
+	suite.Equal([]string{"third", "first", "second"}, waiting)
+	moved := stored.WaitingList[slices.IndexFunc(stored.WaitingList, func(entry WaitingListEntry) bool {
+		return entry.Id == "third"
+	})]
+	suite.Equal("nurse", moved.QueueOverride.ChangedBy)
+	suite.Equal("worsened", moved.QueueOverride.Reason)
+	suite.False(moved.QueueOverride.ChangedAt.IsZero())
+}
+
+func (suite *AmbulanceWlSuite) Test_Move_InvalidRequestsRejected() {
+	for _, test := range []struct {
+		name    string
+		entryId string
+		body    string
+		status  int
+	}{
+		{"several anchors", "third", `{"beforeEntryId": "first", "position": 1, "changedBy": "nurse", "reason": "worsened"}`, http.StatusBadRequest},
+		{"negative position", "third", `{"position": -1, "changedBy": "nurse", "reason": "worsened"}`, http.StatusBadRequest},
+		{"missing reason", "third", `{"beforeEntryId": "first", "changedBy": "nurse"}`, http.StatusBadRequest},
+		{"anchor not waiting", "third", `{"beforeEntryId": "called", "changedBy": "nurse", "reason": "worsened"}`, http.StatusBadRequest},
+		{"entry as anchor", "third", `{"afterEntryId": "third", "changedBy": "nurse", "reason": "worsened"}`, http.StatusBadRequest},
+		{"unknown anchor", "third", `{"afterEntryId": "unknown", "changedBy": "nurse", "reason": "worsened"}`, http.StatusBadRequest},
+		{"unknown entry", "unknown", `{"beforeEntryId": "first", "changedBy": "nurse", "reason": "worsened"}`, http.StatusNotFound},
+		{"entry not waiting", "called", `{"beforeEntryId": "first", "changedBy": "nurse", "reason": "worsened"}`, http.StatusConflict},
+	} {
+		suite.Run(test.name, func() {
+			// ARRANGE
+			db := suite.moveAmbulance()
+			before, _ := db.FindDocument(context.Background(), "move")
+
+			// ACT
+			status := suite.move(db, test.entryId, test.body)
+
+			// ASSERT
+			suite.Equal(test.status, status)
+			after, _ := db.FindDocument(context.Background(), "move")
+			suite.Equal(before.Version, after.Version)
+		})
+	}
+}
+
+func (suite *AmbulanceWlSuite) transfer(db db_service.DbService[Ambulance], body string) int {
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db},
+		"POST", "/waiting-list/source/entries/test-entry/transfer", body,
+		gin.Param{Key: "ambulanceId", Value: "source"},
+		gin.Param{Key: "entryId", Value: "test-entry"},
+	)
+	sut := implAmbulanceWaitingListAPI{}
+	sut.TransferWaitingListEntry(ctx)
+	return recorder.Code
+}
+
+func (suite *AmbulanceWlSuite) Test_Transfer_EntryMovedBetweenAmbulances() {
+	// ARRANGE
+	waitingSince := time.Now().Add(-time.Hour)
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"source": {Id: "source", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "test-patient", WaitingSince: waitingSince, Status: statusCalled},
+		}},
+		"target": {Id: "target"},
+	})
+
+	// ACT
+	status := suite.transfer(db, `{"targetAmbulanceId": "target", "preservePosition": true}`)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, status)
+	source, _ := db.FindDocument(context.Background(), "source")
+	suite.Empty(source.WaitingList)
+	target, _ := db.FindDocument(context.Background(), "target")
+	suite.Require().Len(target.WaitingList, 1)
+	suite.Equal(statusWaiting, target.WaitingList[0].Status)
+	suite.Equal("source", target.WaitingList[0].TransferredFrom)
+	suite.True(waitingSince.Equal(target.WaitingList[0].WaitingSince))
+	suite.True(target.WaitingList[0].QueuedSince.IsZero())
+}
+
+func (suite *AmbulanceWlSuite) Test_Transfer_UnrelatedEntryWithSameIdConflicts() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"source": {Id: "source", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "test-patient", WaitingSince: time.Now()},
+		}},
+		"target": {Id: "target", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "other-patient", WaitingSince: time.Now()},
+		}},
+	})
+
+	// ACT
+	status := suite.transfer(db, `{"targetAmbulanceId": "target"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusConflict, status)
+	source, _ := db.FindDocument(context.Background(), "source")
+	suite.Len(source.WaitingList, 1)
+	target, _ := db.FindDocument(context.Background(), "target")
+	suite.Require().Len(target.WaitingList, 1)
+	suite.Equal("other-patient", target.WaitingList[0].PatientId)
+}
+
+func (suite *AmbulanceWlSuite) Test_Transfer_InterruptedAttemptKeepsTicket() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"source": {Id: "source", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "test-patient", WaitingSince: time.Now()},
+		}},
+		"target": {Id: "target", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "test-patient", WaitingSince: time.Now(), TransferredFrom: "source", TicketNumber: "007"},
+		}},
+	})
+
+	// ACT
+	status := suite.transfer(db, `{"targetAmbulanceId": "target"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, status)
+	source, _ := db.FindDocument(context.Background(), "source")
+	suite.Empty(source.WaitingList)
+	target, _ := db.FindDocument(context.Background(), "target")
+	suite.Require().Len(target.WaitingList, 1)
+	suite.Equal("007", target.WaitingList[0].TicketNumber)
+}
+
+// failingPropertyDb fails the property updates of the ambulance
+type failingPropertyDb struct {
+	db_service.DbService[Ambulance]
+	ambulanceId string
+}
+
+func (this *failingPropertyDb) SetProperty(ctx context.Context, id string, property string, value interface{}, expectedVersion int64) error {
+	if id == this.ambulanceId {
+		return errors.New("database unavailable")
+	}
+	return this.DbService.SetProperty(ctx, id, property, value, expectedVersion)
+}
+
+func (suite *AmbulanceWlSuite) Test_Transfer_TargetRolledBackWhenSourceFails() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"source": {Id: "source", WaitingList: []WaitingListEntry{
+			{Id: "test-entry", PatientId: "test-patient", WaitingSince: time.Now()},
+		}},
+		"target": {Id: "target", WaitingList: []WaitingListEntry{
+			{Id: "other-entry", PatientId: "other-patient", WaitingSince: time.Now()},
+		}},
+	})
+
+	// ACT
+	status := suite.transfer(&failingPropertyDb{DbService: db, ambulanceId: "source"}, `{"targetAmbulanceId": "target"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusBadGateway, status)
+	source, _ := db.FindDocument(context.Background(), "source")
+	suite.Len(source.WaitingList, 1)
+	target, _ := db.FindDocument(context.Background(), "target")
+	suite.Require().Len(target.WaitingList, 1)
+	suite.Equal("other-entry", target.WaitingList[0].Id)
+}
+
+func (suite *AmbulanceWlSuite) Test_LearnedDurations_CachedPerAmbulance() {
+	// ARRANGE
+	visits := &DbServiceMock[Visit]{}
+	visits.
+		On("ListDocuments", mock.Anything, mock.Anything).
+		Return([]*Visit{}, int64(0), nil)
+
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set("visits_db_service", db_service.DbService[Visit](visits))
+	ctx.Request = httptest.NewRequest("POST", "/waiting-list/cached/entries", nil)
+
+	// ACT
+	learnedDurations(ctx, "cached")
+	learnedDurations(ctx, "cached")
+	learnedDurations(ctx, "other")
+
+	// ASSERT
+	visits.AssertNumberOfCalls(suite.T(), "ListDocuments", 2)
+	visits.AssertCalled(suite.T(), "ListDocuments", mock.Anything, mock.MatchedBy(func(query db_service.ListQuery) bool {
+		return query.SortBy == "WaitingSince" && query.Descending && query.Limit == maxLearnedVisits
+	}))
+}
+
+func (suite *AmbulanceWlSuite) Test_LearnedDurations_ExpiredEvicted() {
+	// ARRANGE
+	visits := &DbServiceMock[Visit]{}
+	visits.
+		On("ListDocuments", mock.Anything, mock.Anything).
+		Return([]*Visit{}, int64(0), nil)
+	expired := learnedDurationsKey{visits: visits, ambulanceId: "expired"}
+	learnedDurationsCache.Lock()
+	learnedDurationsCache.entries[expired] = cachedDurations{learnedAt: time.Now().Add(-learnedDurationsTtl)}
+	learnedDurationsCache.Unlock()
+	ctx, _ := newHandlerContext(map[string]interface{}{"visits_db_service": db_service.DbService[Visit](visits)},
+		"POST", "/waiting-list/learned/entries", "")
+
+	// ACT
+	learnedDurations(ctx, "learned")
+
+	// ASSERT
+	learnedDurationsCache.Lock()
+	defer learnedDurationsCache.Unlock()
+	suite.NotContains(learnedDurationsCache.entries, expired)
+	suite.Contains(learnedDurationsCache.entries, learnedDurationsKey{visits: visits, ambulanceId: "learned"})
+}
+
+func (suite *AmbulanceWlSuite) Test_GetByTicket_TicketOfCurrentDay() {
+	// ARRANGE
+	db := db_service.NewMemoryService[Ambulance](db_service.MemoryServiceConfig{})
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	yesterday := now.AddDate(0, 0, -1).Format(time.DateOnly)
+	ambulance := Ambulance{
+		Id: "tickets",
+		WaitingList: []WaitingListEntry{
+			// the entry of today may claim the earlier arrival
+			{Id: "today", PatientId: "p1", TicketNumber: "A001", TicketDay: today, WaitingSince: now.AddDate(0, 0, -2)},
+			{Id: "yesterday", PatientId: "p2", TicketNumber: "A001", TicketDay: yesterday, WaitingSince: now},
+			{Id: "stale", PatientId: "p3", TicketNumber: "A002", TicketDay: yesterday, WaitingSince: now},
+		},
+	}
+	suite.Require().NoError(db.CreateDocument(context.Background(), ambulance.Id, &ambulance))
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceWaitingListAPI{}
+	byTicket := func(ticket string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", db)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "tickets"}, {Key: "ticketNumber", Value: ticket}}
+		ctx.Request = httptest.NewRequest("GET", "/waiting-list/tickets/tickets/"+ticket, nil)
+		sut.GetWaitingListEntryByTicket(ctx)
+		return recorder
+	}
+
+	// ACT
+	current := byTicket("a001")
+	previous := byTicket("A002")
+
+	// ASSERT
+	suite.Equal(http.StatusOK, current.Code)
+	suite.Contains(current.Body.String(), `"id":"today"`)
+	suite.Equal(http.StatusNotFound, previous.Code)
+}
+
+func (suite *AmbulanceWlSuite) Test_Create_DailyTicketNumbersAssigned() {
+	// ARRANGE
+	db := db_service.NewMemoryService[Ambulance](db_service.MemoryServiceConfig{})
+	ambulance := Ambulance{
+		Id:            "tickets",
+		Settings:      AmbulanceSettings{TicketPrefix: "A"},
+		TicketCounter: TicketCounter{Day: "2000-01-01", Last: 41},
+	}
+	suite.Require().NoError(db.CreateDocument(context.Background(), ambulance.Id, &ambulance))
+	patients := db_service.NewMemoryService[Patient](db_service.MemoryServiceConfig{})
+	for _, patient := range []Patient{{Id: "first-patient", Name: "First"}, {Id: "second-patient", Name: "Second"}} {
+		suite.Require().NoError(patients.CreateDocument(context.Background(), patient.Id, &patient))
+	}
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceWaitingListAPI{}
+
+	// ACT
+	for _, patientId := range []string{"first-patient", "second-patient"} {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", db)
+		ctx.Set("patients_db_service", patients)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "tickets"}}
+		ctx.Request = httptest.NewRequest("POST", "/waiting-list/tickets/entries",
+			strings.NewReader(`{"patientId": "`+patientId+`", "ticketNumber": "X999", "waitingSince": "2038-12-24T10:05:00Z"}`))
+		sut.CreateWaitingListEntry(ctx)
+		suite.Require().Equal(http.StatusOK, recorder.Code)
+	}
+
+	// ASSERT
+	stored, _ := db.FindDocument(context.Background(), "tickets")
+	tickets := []string{}
+	for _, entry := range stored.WaitingList {
+		tickets = append(tickets, entry.TicketNumber)
+	}
+	suite.ElementsMatch([]string{"A001", "A002"}, tickets)
+	suite.Equal(int32(2), stored.TicketCounter.Last)
 }
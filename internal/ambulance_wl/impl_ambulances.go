@@ -1,13 +1,20 @@
 package ambulance_wl
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/xlukacs/ambulance-webapi/internal/db_service"
 )
 
+const (
+	defaultAmbulancesPageSize = 20
+	maxAmbulancesPageSize     = 100
+)
+
 // CreateAmbulance - Saves new ambulance definition
 func (this *implAmbulancesAPI) CreateAmbulance(ctx *gin.Context) {
 	// get db service from context
@@ -52,7 +59,7 @@ func (this *implAmbulancesAPI) CreateAmbulance(ctx *gin.Context) {
 		ambulance.Id = uuid.New().String()
 	}
 
-	err = db.CreateDocument(ctx, ambulance.Id, &ambulance)
+	err = db.CreateDocument(ctx, ambulance.Id, ambulance.withEmptyArrays())
 
 	switch err {
 	case nil:
@@ -132,3 +139,117 @@ func (this *implAmbulancesAPI) DeleteAmbulance(ctx *gin.Context) {
 			})
 	}
 }
+
+// GetAmbulance - Provides details about specific ambulance
+func (this *implAmbulancesAPI) GetAmbulance(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		// return nil ambulance - no need to update it in db
+		return nil, ambulance, http.StatusOK
+	})
+}
+
+// GetAmbulances - Provides the list of ambulances
+func (this *implAmbulancesAPI) GetAmbulances(ctx *gin.Context) {
+	// get db service from context
+	value, exists := ctx.Get("db_service")
+	if !exists {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "db_service not found",
+				"error":   "db_service not found",
+			})
+		return
+	}
+
+	db, ok := value.(db_service.DbService[Ambulance])
+	if !ok {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "db_service context is not of type db_service.DbService",
+				"error":   "cannot cast db_service context to db_service.DbService",
+			})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Query parameter offset must be a non-negative integer",
+			})
+		return
+	}
+
+	limit, err := strconv.ParseInt(ctx.DefaultQuery("limit", strconv.Itoa(defaultAmbulancesPageSize)), 10, 64)
+	if err != nil || limit < 1 || limit > maxAmbulancesPageSize {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": fmt.Sprintf("Query parameter limit must be an integer between 1 and %v", maxAmbulancesPageSize),
+			})
+		return
+	}
+
+	query := db_service.ListQuery{
+		SortBy: "name",
+		Offset: offset,
+		Limit:  limit,
+	}
+	if name := ctx.Query("name"); name != "" {
+		query.Contains = map[string]string{"name": name}
+	}
+
+	ambulances, total, err := db.ListDocuments(ctx, query)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load ambulances from database",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, ambulances)
+}
+
+// UpdateAmbulance - Updates name or room number of specific ambulance
+func (this *implAmbulancesAPI) UpdateAmbulance(ctx *gin.Context) {
+	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+		var patch AmbulancePatch
+
+		if err := c.ShouldBindJSON(&patch); err != nil {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			}, http.StatusBadRequest
+		}
+
+		if patch.Version != 0 && patch.Version != ambulance.Version {
+			return nil, gin.H{
+				"status":  http.StatusConflict,
+				"message": "Ambulance was modified in between, reload it and retry the request",
+			}, http.StatusConflict
+		}
+
+		if patch.Name != "" {
+			ambulance.Name = patch.Name
+		}
+
+		if patch.RoomNumber != "" {
+			ambulance.RoomNumber = patch.RoomNumber
+		}
+
+		return ambulance, ambulance, http.StatusOK
+	})
+}
@@ -0,0 +1,171 @@
+package ambulance_wl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type AmbulancesSuite struct {
+	suite.Suite
+	dbService db_service.DbService[Ambulance]
+}
+
+func TestAmbulancesSuite(t *testing.T) {
+	suite.Run(t, new(AmbulancesSuite))
+}
+
+func (suite *AmbulancesSuite) SetupTest() {
+	suite.dbService = db_service.NewMemoryService[Ambulance](db_service.MemoryServiceConfig{})
+	for _, ambulance := range []Ambulance{
+		{Id: "a", Name: "Zubná ambulancia"},
+		{Id: "b", Name: "Ambulancia všeobecného lekárstva"},
+		{Id: "c", Name: "Detská ambulancia"},
+	} {
+		suite.Require().NoError(suite.dbService.CreateDocument(context.Background(), ambulance.Id, &ambulance))
+	}
+}
+
+func (suite *AmbulancesSuite) Test_GetAmbulances_FilteredAndPaged() {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Set("db_service", suite.dbService)
+	ctx.Request = httptest.NewRequest("GET", "/api/ambulance?name=AMBULANCIA&offset=1&limit=1", nil)
+
+	sut := implAmbulancesAPI{}
+
+	// ACT
+	sut.GetAmbulances(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.Equal("3", recorder.Header().Get("X-Total-Count"))
+	var ambulances []Ambulance
+	suite.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &ambulances))
+	suite.Require().Len(ambulances, 1)
+	suite.Equal("c", ambulances[0].Id)
+}
+
+func (suite *AmbulancesSuite) Test_GetAmbulances_InvalidLimit() {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Set("db_service", suite.dbService)
+	ctx.Request = httptest.NewRequest("GET", "/api/ambulance?limit=1000", nil)
+
+	sut := implAmbulancesAPI{}
+
+	// ACT
+	sut.GetAmbulances(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusBadRequest, recorder.Code)
+}
+
+func (suite *AmbulancesSuite) Test_DeleteCondition_BlockedWhileUsed() {
+	// ARRANGE
+	ambulance := Ambulance{
+		Id:                   "conditions",
+		PredefinedConditions: []Condition{{Code: "fever", Value: "Teploty"}},
+		WaitingList:          []WaitingListEntry{{Id: "entry", PatientId: "patient", Condition: Condition{Code: "fever"}}},
+	}
+	suite.Require().NoError(suite.dbService.CreateDocument(context.Background(), ambulance.Id, &ambulance))
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceConditionsAPI{}
+	deleteCondition := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", suite.dbService)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "conditions"}, {Key: "conditionCode", Value: "fever"}}
+		ctx.Request = httptest.NewRequest("DELETE", "/waiting-list/conditions/condition/fever"+query, nil)
+		sut.DeleteCondition(ctx)
+		return recorder
+	}
+
+	// ACT
+	blocked := deleteCondition("")
+	forced := deleteCondition("?force=true")
+
+	// ASSERT
+	suite.Equal(http.StatusConflict, blocked.Code)
+	suite.Equal(http.StatusNoContent, forced.Code)
+	suite.NotEmpty(forced.Header().Get("Warning"))
+	stored, _ := suite.dbService.FindDocument(context.Background(), "conditions")
+	suite.Empty(stored.PredefinedConditions)
+}
+
+func (suite *AmbulancesSuite) Test_CreateCondition_OverridesCatalogue() {
+	// ARRANGE
+	catalogue := db_service.NewMemoryService[CatalogueCondition](db_service.MemoryServiceConfig{})
+	suite.Require().NoError(catalogue.CreateDocument(context.Background(), "icd-10:J00", &CatalogueCondition{
+		Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis", TypicalDurationMinutes: 15,
+	}))
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceConditionsAPI{}
+	createCondition := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", suite.dbService)
+		ctx.Set("catalogue_db_service", catalogue)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "a"}}
+		ctx.Request = httptest.NewRequest("POST", "/waiting-list/a/condition", strings.NewReader(body))
+		sut.CreateCondition(ctx)
+		return recorder
+	}
+
+	// ACT
+	created := createCondition(`{"code": "J00", "typicalDurationMinutes": 30}`)
+	unknown := createCondition(`{"code": "X99", "value": "Unknown"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, created.Code)
+	var resolved Condition
+	suite.Require().NoError(json.Unmarshal(created.Body.Bytes(), &resolved))
+	suite.Equal("Acute nasopharyngitis", resolved.Value)
+	suite.Equal(int32(30), resolved.TypicalDurationMinutes)
+	suite.Equal(http.StatusBadRequest, unknown.Code)
+	stored, _ := suite.dbService.FindDocument(context.Background(), "a")
+	suite.Equal([]Condition{{Code: "J00", TypicalDurationMinutes: 30}}, stored.PredefinedConditions)
+}
+
+func (suite *AmbulancesSuite) Test_CreateCondition_AcceptsLocalCodesWhileCatalogueIsEmpty() {
+	// ARRANGE
+	catalogue := db_service.NewMemoryService[CatalogueCondition](db_service.MemoryServiceConfig{})
+
+	gin.SetMode(gin.TestMode)
+	sut := implAmbulanceConditionsAPI{}
+	createCondition := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("db_service", suite.dbService)
+		ctx.Set("catalogue_db_service", catalogue)
+		ctx.Params = []gin.Param{{Key: "ambulanceId", Value: "a"}}
+		ctx.Request = httptest.NewRequest("POST", "/waiting-list/a/condition", strings.NewReader(body))
+		sut.CreateCondition(ctx)
+		return recorder
+	}
+
+	// ACT
+	local := createCondition(`{"code": "X99", "value": "Unknown"}`)
+	suite.Require().NoError(catalogue.CreateDocument(context.Background(), "icd-10:J00", &CatalogueCondition{
+		Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis",
+	}))
+	rejected := createCondition(`{"code": "X98", "value": "Unknown"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, local.Code)
+	suite.Contains(local.Header().Get("Warning"), "X99")
+	suite.Equal(http.StatusBadRequest, rejected.Code)
+}
@@ -0,0 +1,263 @@
+package ambulance_wl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+const (
+	defaultCataloguePageSize = 50
+	maxCataloguePageSize     = 500
+)
+
+// code systems of the catalogue conditions
+var catalogueSystems = []string{"icd-10", "snomed-ct", "local"}
+
+// conditionCatalogue provides the db service of the condition catalogue from the context
+func conditionCatalogue(ctx *gin.Context) (db_service.DbService[CatalogueCondition], error) {
+	value, exists := ctx.Get("catalogue_db_service")
+	if !exists {
+		return nil, fmt.Errorf("catalogue_db_service not found")
+	}
+	db, ok := value.(db_service.DbService[CatalogueCondition])
+	if !ok {
+		return nil, fmt.Errorf("cannot cast catalogue_db_service context to db_service.DbService")
+	}
+	return db, nil
+}
+
+// errAmbiguousCode - the code is in the catalogue for several code systems
+var errAmbiguousCode = fmt.Errorf("code is in the catalogue for several code systems")
+
+// catalogueConditionId is the key of the condition in the catalogue, the same code may be used by several systems
+func catalogueConditionId(system string, code string) string {
+	return system + ":" + code
+}
+
+// findCatalogueCondition looks up the condition by its code, and by its system if provided
+func findCatalogueCondition(ctx context.Context, db db_service.DbService[CatalogueCondition], condition Condition) (*CatalogueCondition, error) {
+	if condition.System != "" {
+		return db.FindDocument(ctx, catalogueConditionId(condition.System, condition.Code))
+	}
+	found, _, err := db.ListDocuments(ctx, db_service.ListQuery{
+		Equals: map[string]string{"Code": condition.Code},
+		Limit:  2,
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case len(found) == 0:
+		return nil, db_service.ErrNotFound
+	case len(found) > 1:
+		return nil, errAmbiguousCode
+	}
+	return found[0], nil
+}
+
+// catalogueCondition looks up the code of the condition in the catalogue, the response content and
+// status describe the failure if the condition is not valid. Until the catalogue is filled, the codes
+// not found are accepted as local ones - no catalogue condition and no response content are returned.
+func catalogueCondition(ctx *gin.Context, condition Condition) (*CatalogueCondition, interface{}, int) {
+	db, err := conditionCatalogue(ctx)
+	if err != nil {
+		return nil, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Condition catalogue not available",
+			"error":   err.Error(),
+		}, http.StatusInternalServerError
+	}
+
+	if condition.System != "" && !slices.Contains(catalogueSystems, condition.System) {
+		return nil, gin.H{
+			"status":  http.StatusBadRequest,
+			"message": fmt.Sprintf("Condition code system must be one of %v", catalogueSystems),
+		}, http.StatusBadRequest
+	}
+
+	found, err := findCatalogueCondition(ctx.Request.Context(), db, condition)
+	if err == db_service.ErrNotFound {
+		_, total, err := db.ListDocuments(ctx.Request.Context(), db_service.ListQuery{Limit: 1})
+		if err == nil && total == 0 {
+			log.Printf("Condition code %v accepted as local, the condition catalogue is empty", condition.Code)
+			ctx.Header("Warning", fmt.Sprintf(`199 - "condition code %v is not validated, the condition catalogue is empty"`, condition.Code))
+			return nil, nil, http.StatusOK
+		}
+		return nil, gin.H{
+			"status":  http.StatusBadRequest,
+			"message": fmt.Sprintf("Condition code %v is not in the catalogue", condition.Code),
+		}, http.StatusBadRequest
+	}
+
+	switch err {
+	case nil:
+		return found, nil, http.StatusOK
+	case errAmbiguousCode:
+		return nil, gin.H{
+			"status":  http.StatusBadRequest,
+			"message": fmt.Sprintf("Condition code %v is ambiguous, provide its code system", condition.Code),
+		}, http.StatusBadRequest
+	default:
+		return nil, gin.H{
+			"status":  http.StatusBadGateway,
+			"message": "Failed to load condition from catalogue",
+			"error":   err.Error(),
+		}, http.StatusBadGateway
+	}
+}
+
+// inherit takes the properties not overridden by the condition from the catalogue, if there is any
+func (this *Condition) inherit(catalogue *CatalogueCondition) {
+	if catalogue == nil {
+		return
+	}
+	if this.System == "" {
+		this.System = catalogue.System
+	}
+	if this.Value == "" {
+		this.Value = catalogue.Value
+	}
+	if this.Reference == "" {
+		this.Reference = catalogue.Reference
+	}
+	if this.TypicalDurationMinutes == 0 {
+		this.TypicalDurationMinutes = catalogue.TypicalDurationMinutes
+	}
+}
+
+// resolvedConditions are the conditions completed from the catalogue, the conditions which cannot
+// be looked up are provided as they are. The ambulance keeps only its overrides.
+func resolvedConditions(ctx *gin.Context, conditions []Condition) []Condition {
+	resolved := slices.Clone(conditions)
+	db, err := conditionCatalogue(ctx)
+	if err != nil {
+		log.Printf("Conditions are not resolved: %v", err)
+		return resolved
+	}
+	for i := range resolved {
+		if catalogue, err := findCatalogueCondition(ctx.Request.Context(), db, resolved[i]); err == nil {
+			resolved[i].inherit(catalogue)
+		} else if err != db_service.ErrNotFound {
+			log.Printf("Failed to resolve condition %v: %v", resolved[i].Code, err)
+		}
+	}
+	return resolved
+}
+
+// GetCatalogueCondition - Provides the condition of the hospital-wide catalogue
+func (this *implConditionCatalogueAPI) GetCatalogueCondition(ctx *gin.Context) {
+	db, err := conditionCatalogue(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Condition catalogue not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	condition, err := db.FindDocument(ctx, catalogueConditionId(ctx.Param("system"), ctx.Param("conditionCode")))
+
+	switch err {
+	case nil:
+		ctx.JSON(http.StatusOK, condition)
+	case db_service.ErrNotFound:
+		ctx.JSON(
+			http.StatusNotFound,
+			gin.H{
+				"status":  "Not Found",
+				"message": "Condition not found",
+				"error":   err.Error(),
+			},
+		)
+	default:
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load condition from database",
+				"error":   err.Error(),
+			})
+	}
+}
+
+// GetCatalogueConditions - Provides the conditions of the hospital-wide catalogue
+func (this *implConditionCatalogueAPI) GetCatalogueConditions(ctx *gin.Context) {
+	db, err := conditionCatalogue(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Condition catalogue not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Query parameter offset must be a non-negative integer",
+			})
+		return
+	}
+
+	limit, err := strconv.ParseInt(ctx.DefaultQuery("limit", strconv.Itoa(defaultCataloguePageSize)), 10, 64)
+	if err != nil || limit < 1 || limit > maxCataloguePageSize {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": fmt.Sprintf("Query parameter limit must be an integer between 1 and %v", maxCataloguePageSize),
+			})
+		return
+	}
+
+	query := db_service.ListQuery{
+		SortBy: "Id",
+		Offset: offset,
+		Limit:  limit,
+	}
+	if system := ctx.Query("system"); system != "" {
+		if !slices.Contains(catalogueSystems, system) {
+			ctx.JSON(
+				http.StatusBadRequest,
+				gin.H{
+					"status":  "Bad Request",
+					"message": fmt.Sprintf("Query parameter system must be one of %v", catalogueSystems),
+				})
+			return
+		}
+		query.Equals = map[string]string{"System": system}
+	}
+	if value := ctx.Query("value"); value != "" {
+		query.Contains = map[string]string{"value": value}
+	}
+
+	conditions, total, err := db.ListDocuments(ctx, query)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load conditions from database",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, conditions)
+}
@@ -0,0 +1,231 @@
+package ambulance_wl
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+// respondFhir serializes the resource with the FHIR content type
+func respondFhir(ctx *gin.Context, status int, resource interface{}) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = json.Marshal(fhirOutcome("exception", err.Error()))
+	}
+	ctx.Data(status, fhirContentType, body)
+}
+
+// fhirOutcome is the FHIR way of reporting errors
+func fhirOutcome(code string, diagnostics string) fhirOperationOutcome {
+	return fhirOperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []fhirIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
+
+// fhirOrigin is the scheme and host the client used to reach the service, also behind a proxy
+func fhirOrigin(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := ctx.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	host := ctx.Request.Host
+	if forwarded := ctx.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
+
+// fhirBase is the absolute url of the FHIR resources of the ambulance
+func fhirBase(ctx *gin.Context) string {
+	path := ctx.Request.URL.Path
+	prefix, _, found := strings.Cut(path, "/fhir/")
+	if !found {
+		prefix = "/api"
+	}
+	return fhirOrigin(ctx) + prefix + "/fhir/" + url.PathEscape(ctx.Param("ambulanceId"))
+}
+
+// fhirSelf is the absolute url of the request
+func fhirSelf(ctx *gin.Context) string {
+	return fhirOrigin(ctx) + ctx.Request.URL.RequestURI()
+}
+
+// loadFhirAmbulance responds with the operation outcome and returns false if the ambulance cannot be provided
+func loadFhirAmbulance(ctx *gin.Context) (*Ambulance, bool) {
+	value, _ := ctx.Get("db_service")
+	db, ok := value.(db_service.DbService[Ambulance])
+	if !ok {
+		respondFhir(ctx, http.StatusInternalServerError, fhirOutcome("exception", "db_service context is not of type db_service.DbService"))
+		return nil, false
+	}
+
+	ambulance, err := db.FindDocument(ctx, ctx.Param("ambulanceId"))
+	switch err {
+	case nil:
+		return ambulance, true
+	case db_service.ErrNotFound:
+		respondFhir(ctx, http.StatusNotFound, fhirOutcome("not-found", "Ambulance not found"))
+	default:
+		respondFhir(ctx, http.StatusBadGateway, fhirOutcome("transient", "Failed to load ambulance from database: "+err.Error()))
+	}
+	return nil, false
+}
+
+// fhirCodeSystemsOf provides the code systems of the conditions of the entries, the entries created
+// before the systems were recorded are looked up in the catalogue. The codes which cannot be looked up
+// are exported without the system.
+func fhirCodeSystemsOf(ctx *gin.Context, entries []WaitingListEntry) map[string]string {
+	systems := map[string]string{}
+	db, err := conditionCatalogue(ctx)
+	if err != nil {
+		log.Printf("Code systems of the conditions are not resolved: %v", err)
+		db = nil
+	}
+	resolved := map[string]bool{}
+	for _, entry := range entries {
+		code := entry.Condition.Code
+		if code == "" || resolved[code] {
+			continue
+		}
+		resolved[code] = true
+		if entry.Condition.System != "" {
+			systems[code] = fhirCodeSystems[entry.Condition.System]
+			continue
+		}
+		if db == nil {
+			continue
+		}
+		if catalogue, err := findCatalogueCondition(ctx.Request.Context(), db, entry.Condition); err == nil {
+			systems[code] = fhirCodeSystems[catalogue.System]
+		} else if err != db_service.ErrNotFound {
+			log.Printf("Failed to resolve code system of condition %v: %v", code, err)
+		}
+	}
+	return systems
+}
+
+// GetFhirAppointment - Provides the schedule as FHIR Appointment resource
+func (this *implFhirAPI) GetFhirAppointment(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	for _, schedule := range ambulance.Schedules {
+		if schedule.Id == ctx.Param("appointmentId") {
+			respondFhir(ctx, http.StatusOK, schedule.fhirAppointment(ambulance))
+			return
+		}
+	}
+	respondFhir(ctx, http.StatusNotFound, fhirOutcome("not-found", "Appointment not found"))
+}
+
+// GetFhirAppointments - Provides the schedules of the ambulance as Bundle of FHIR Appointment resources
+func (this *implFhirAPI) GetFhirAppointments(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	base := fhirBase(ctx)
+	bundle := searchsetBundle(fhirSelf(ctx))
+	for _, schedule := range ambulance.Schedules {
+		bundle.add(base, "Appointment", schedule.Id, schedule.fhirAppointment(ambulance))
+	}
+	respondFhir(ctx, http.StatusOK, bundle)
+}
+
+// GetFhirEncounter - Provides the waiting list entry as FHIR Encounter resource
+func (this *implFhirAPI) GetFhirEncounter(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	for _, entry := range ambulance.WaitingList {
+		if entry.Id == ctx.Param("encounterId") {
+			codeSystems := fhirCodeSystemsOf(ctx, []WaitingListEntry{entry})
+			respondFhir(ctx, http.StatusOK, entry.fhirEncounter(ambulance, time.Now(), codeSystems))
+			return
+		}
+	}
+	respondFhir(ctx, http.StatusNotFound, fhirOutcome("not-found", "Encounter not found"))
+}
+
+// GetFhirEncounters - Provides the waiting list entries of the ambulance as Bundle of FHIR Encounter resources
+func (this *implFhirAPI) GetFhirEncounters(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	base := fhirBase(ctx)
+	bundle := searchsetBundle(fhirSelf(ctx))
+	codeSystems := fhirCodeSystemsOf(ctx, ambulance.WaitingList)
+	now := time.Now()
+	for _, entry := range ambulance.WaitingList {
+		bundle.add(base, "Encounter", entry.Id, entry.fhirEncounter(ambulance, now, codeSystems))
+	}
+	respondFhir(ctx, http.StatusOK, bundle)
+}
+
+// GetFhirHealthcareService - Provides the ambulance as FHIR HealthcareService resource
+func (this *implFhirAPI) GetFhirHealthcareService(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	// the ambulance is the only healthcare service on its base
+	if ctx.Param("healthcareServiceId") != ambulance.Id {
+		respondFhir(ctx, http.StatusNotFound, fhirOutcome("not-found", "HealthcareService not found"))
+		return
+	}
+	respondFhir(ctx, http.StatusOK, ambulance.fhirHealthcareService())
+}
+
+// GetFhirHealthcareServices - Provides the ambulance as Bundle of FHIR HealthcareService resources
+func (this *implFhirAPI) GetFhirHealthcareServices(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	bundle := searchsetBundle(fhirSelf(ctx))
+	bundle.add(fhirBase(ctx), "HealthcareService", ambulance.Id, ambulance.fhirHealthcareService())
+	respondFhir(ctx, http.StatusOK, bundle)
+}
+
+// GetFhirLocation - Provides the room of the ambulance as FHIR Location resource
+func (this *implFhirAPI) GetFhirLocation(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	for _, room := range ambulance.Rooms {
+		if room.Id == ctx.Param("locationId") {
+			respondFhir(ctx, http.StatusOK, room.fhirLocation())
+			return
+		}
+	}
+	respondFhir(ctx, http.StatusNotFound, fhirOutcome("not-found", "Location not found"))
+}
+
+// GetFhirLocations - Provides the rooms of the ambulance as Bundle of FHIR Location resources
+func (this *implFhirAPI) GetFhirLocations(ctx *gin.Context) {
+	ambulance, ok := loadFhirAmbulance(ctx)
+	if !ok {
+		return
+	}
+	base := fhirBase(ctx)
+	bundle := searchsetBundle(fhirSelf(ctx))
+	for _, room := range ambulance.Rooms {
+		bundle.add(base, "Location", room.Id, room.fhirLocation())
+	}
+	respondFhir(ctx, http.StatusOK, bundle)
+}
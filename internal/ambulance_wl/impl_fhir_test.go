@@ -0,0 +1,67 @@
+package ambulance_wl
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type FhirSuite struct {
+	suite.Suite
+}
+
+func TestFhirSuite(t *testing.T) {
+	suite.Run(t, new(FhirSuite))
+}
+
+func (suite *FhirSuite) Test_FhirEncounters_StatusesAndCodeSystems() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"fhir": {
+			Id: "fhir",
+			WaitingList: []WaitingListEntry{
+				{Id: "waiting-entry", PatientId: "p1", WaitingSince: time.Now().Add(-time.Hour),
+					Condition: Condition{Code: "J00", Value: "Nádcha"}},
+				{Id: "planned-entry", PatientId: "p2", WaitingSince: time.Now().Add(time.Hour)},
+				{Id: "treated-entry", PatientId: "p3", WaitingSince: time.Now().Add(-time.Hour),
+					Status: statusInTreatment, ActualStart: time.Now()},
+			},
+		},
+	})
+	catalogue := newMemoryStore(suite.T(), map[string]CatalogueCondition{
+		"icd-10:J00": {Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis"},
+	})
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db, "catalogue_db_service": catalogue},
+		"GET", "http://hospital.example/api/fhir/fhir/Encounter", "",
+		gin.Param{Key: "ambulanceId", Value: "fhir"},
+	)
+	sut := implFhirAPI{}
+
+	// ACT
+	sut.GetFhirEncounters(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.Equal(fhirContentType, recorder.Header().Get("Content-Type"))
+	var bundle struct {
+		ResourceType string
+		Total        int
+		Entry        []struct {
+			FullUrl  string
+			Resource fhirEncounter
+		}
+	}
+	suite.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &bundle))
+	suite.Equal("Bundle", bundle.ResourceType)
+	suite.Equal(3, bundle.Total)
+	suite.Equal("http://hospital.example/api/fhir/fhir/Encounter/waiting-entry", bundle.Entry[0].FullUrl)
+	suite.Equal("arrived", bundle.Entry[0].Resource.Status)
+	suite.Equal("http://hl7.org/fhir/sid/icd-10", bundle.Entry[0].Resource.ReasonCode[0].Coding[0].System)
+	suite.Equal("planned", bundle.Entry[1].Resource.Status)
+	suite.Equal("in-progress", bundle.Entry[2].Resource.Status)
+}
@@ -0,0 +1,484 @@
+package ambulance_wl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+const (
+	defaultPatientsPageSize = 20
+	maxPatientsPageSize     = 100
+)
+
+var patientGenders = []string{"male", "female", "other", "unknown"}
+
+// patientRegistry provides the db service of the patient registry from the context
+func patientRegistry(ctx *gin.Context) (db_service.DbService[Patient], error) {
+	value, exists := ctx.Get("patients_db_service")
+	if !exists {
+		return nil, fmt.Errorf("patients_db_service not found")
+	}
+	db, ok := value.(db_service.DbService[Patient])
+	if !ok {
+		return nil, fmt.Errorf("cannot cast patients_db_service context to db_service.DbService")
+	}
+	return db, nil
+}
+
+// registeredPatient looks up the patient in the registry, the response content
+// and status describe the failure if the patient cannot be provided
+func registeredPatient(ctx *gin.Context, patientId string) (*Patient, interface{}, int) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		return nil, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Patient registry not available",
+			"error":   err.Error(),
+		}, http.StatusInternalServerError
+	}
+
+	patient, err := db.FindDocument(ctx.Request.Context(), patientId)
+	switch err {
+	case nil:
+		return patient, nil, http.StatusOK
+	case db_service.ErrNotFound:
+		return nil, gin.H{
+			"status":  http.StatusBadRequest,
+			"message": fmt.Sprintf("Patient %v is not registered", patientId),
+		}, http.StatusBadRequest
+	default:
+		return nil, gin.H{
+			"status":  http.StatusBadGateway,
+			"message": "Failed to load patient from database",
+			"error":   err.Error(),
+		}, http.StatusBadGateway
+	}
+}
+
+// patientAmbulances provides the db service of the ambulances referring to the patients from the context
+func patientAmbulances(ctx *gin.Context) (db_service.DbService[Ambulance], error) {
+	value, exists := ctx.Get("db_service")
+	if !exists {
+		return nil, fmt.Errorf("db_service not found")
+	}
+	db, ok := value.(db_service.DbService[Ambulance])
+	if !ok {
+		return nil, fmt.Errorf("cannot cast db_service context to db_service.DbService")
+	}
+	return db, nil
+}
+
+// forEachAmbulance visits all ambulances page by page, stops at the first failure of the visit
+func forEachAmbulance(ctx context.Context, db db_service.DbService[Ambulance], visit func(ambulance *Ambulance) error) error {
+	for offset := int64(0); ; offset += maintenancePageSize {
+		ambulances, total, err := db.ListDocuments(ctx, db_service.ListQuery{
+			SortBy: "Id",
+			Offset: offset,
+			Limit:  maintenancePageSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, ambulance := range ambulances {
+			if err := visit(ambulance); err != nil {
+				return err
+			}
+		}
+		if len(ambulances) == 0 || offset+maintenancePageSize >= total {
+			return nil
+		}
+	}
+}
+
+// renamePatient refreshes the name of the patient in the waiting lists, the waiting list
+// entries keep the copy of the name for the displays and the other hospital systems
+func renamePatient(ctx *gin.Context, db db_service.DbService[Ambulance], patient *Patient) error {
+	isRenamed := func(entry WaitingListEntry) bool {
+		return entry.PatientId == patient.Id && entry.Name != patient.Name
+	}
+	return forEachAmbulance(ctx, db, func(ambulance *Ambulance) error {
+		if !slices.ContainsFunc(ambulance.WaitingList, isRenamed) {
+			return nil
+		}
+		renamed, err := changeWaitingList(ctx, db, ambulance.Id, func(ambulance *Ambulance) error {
+			for i := range ambulance.WaitingList {
+				if isRenamed(ambulance.WaitingList[i]) {
+					ambulance.WaitingList[i].Name = patient.Name
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		publishWaitingList(ctx, renamed)
+		return nil
+	})
+}
+
+func (this *Patient) validate() error {
+	if this.Name == "" {
+		return fmt.Errorf("patient name is required")
+	}
+	if this.BirthDate != "" {
+		if _, err := time.Parse(time.DateOnly, this.BirthDate); err != nil {
+			return fmt.Errorf("birth date must be in YYYY-MM-DD format")
+		}
+	}
+	if this.Gender != "" && !slices.Contains(patientGenders, this.Gender) {
+		return fmt.Errorf("gender must be one of %v", patientGenders)
+	}
+	return nil
+}
+
+// CreatePatient - Registers new patient
+func (this *implPatientsAPI) CreatePatient(ctx *gin.Context) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Patient registry not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	patient := Patient{}
+	if err := ctx.BindJSON(&patient); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	if err := patient.validate(); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Invalid patient",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	if patient.Id == "" || patient.Id == "@new" {
+		patient.Id = uuid.NewString()
+	}
+
+	err = db.CreateDocument(ctx, patient.Id, &patient)
+
+	switch err {
+	case nil:
+		ctx.JSON(
+			http.StatusCreated,
+			patient,
+		)
+	case db_service.ErrConflict:
+		ctx.JSON(
+			http.StatusConflict,
+			gin.H{
+				"status":  "Conflict",
+				"message": "Patient already exists",
+				"error":   err.Error(),
+			},
+		)
+	default:
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to create patient in database",
+				"error":   err.Error(),
+			},
+		)
+	}
+}
+
+// DeletePatient - Deletes specific patient
+func (this *implPatientsAPI) DeletePatient(ctx *gin.Context) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Patient registry not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ambulances, err := patientAmbulances(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Ambulances not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	// the waiting list entries and schedules must not refer to the unknown patient
+	patientId := ctx.Param("patientId")
+	referencedBy := []string{}
+	err = forEachAmbulance(ctx, ambulances, func(ambulance *Ambulance) error {
+		if slices.ContainsFunc(ambulance.WaitingList, func(entry WaitingListEntry) bool {
+			return entry.PatientId == patientId && !entry.isFinished()
+		}) || slices.ContainsFunc(ambulance.Schedules, func(schedule Schedule) bool {
+			return schedule.PatientId == patientId
+		}) {
+			referencedBy = append(referencedBy, ambulance.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load ambulances from database",
+				"error":   err.Error(),
+			})
+		return
+	}
+	if len(referencedBy) > 0 {
+		ctx.JSON(
+			http.StatusConflict,
+			gin.H{
+				"status":     "Conflict",
+				"message":    "Patient is waiting or scheduled in the ambulances",
+				"ambulances": referencedBy,
+			})
+		return
+	}
+
+	err = db.DeleteDocument(ctx, patientId)
+
+	switch err {
+	case nil:
+		ctx.AbortWithStatus(http.StatusNoContent)
+	case db_service.ErrNotFound:
+		ctx.JSON(
+			http.StatusNotFound,
+			gin.H{
+				"status":  "Not Found",
+				"message": "Patient not found",
+				"error":   err.Error(),
+			},
+		)
+	default:
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to delete patient from database",
+				"error":   err.Error(),
+			})
+	}
+}
+
+// GetPatient - Provides details about specific patient
+func (this *implPatientsAPI) GetPatient(ctx *gin.Context) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Patient registry not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	patient, err := db.FindDocument(ctx, ctx.Param("patientId"))
+
+	switch err {
+	case nil:
+		ctx.JSON(http.StatusOK, patient)
+	case db_service.ErrNotFound:
+		ctx.JSON(
+			http.StatusNotFound,
+			gin.H{
+				"status":  "Not Found",
+				"message": "Patient not found",
+				"error":   err.Error(),
+			},
+		)
+	default:
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load patient from database",
+				"error":   err.Error(),
+			})
+	}
+}
+
+// GetPatients - Provides the list of registered patients
+func (this *implPatientsAPI) GetPatients(ctx *gin.Context) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Patient registry not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Query parameter offset must be a non-negative integer",
+			})
+		return
+	}
+
+	limit, err := strconv.ParseInt(ctx.DefaultQuery("limit", strconv.Itoa(defaultPatientsPageSize)), 10, 64)
+	if err != nil || limit < 1 || limit > maxPatientsPageSize {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": fmt.Sprintf("Query parameter limit must be an integer between 1 and %v", maxPatientsPageSize),
+			})
+		return
+	}
+
+	query := db_service.ListQuery{
+		SortBy: "name",
+		Offset: offset,
+		Limit:  limit,
+	}
+	if name := ctx.Query("name"); name != "" {
+		query.Contains = map[string]string{"name": name}
+	}
+
+	patients, total, err := db.ListDocuments(ctx, query)
+	if err != nil {
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load patients from database",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, patients)
+}
+
+// UpdatePatient - Updates specific patient
+func (this *implPatientsAPI) UpdatePatient(ctx *gin.Context) {
+	db, err := patientRegistry(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Patient registry not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	patient := Patient{}
+	if err := ctx.BindJSON(&patient); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Invalid request body",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	if err := patient.validate(); err != nil {
+		ctx.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"status":  "Bad Request",
+				"message": "Invalid patient",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	ambulances, err := patientAmbulances(ctx)
+	if err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "Ambulances not available",
+				"error":   err.Error(),
+			})
+		return
+	}
+
+	// the id is referenced by the waiting lists and cannot be changed
+	patient.Id = ctx.Param("patientId")
+	err = db.UpdateDocument(ctx, patient.Id, &patient)
+
+	switch err {
+	case nil:
+		// repeating the update refreshes the names that were not refreshed
+		if err := renamePatient(ctx, ambulances, &patient); err != nil {
+			log.Printf("Failed to refresh the name of the patient %v in the waiting lists: %v", patient.Id, err)
+			ctx.JSON(
+				http.StatusBadGateway,
+				gin.H{
+					"status":  "Bad Gateway",
+					"message": "Patient updated, but failed to refresh the waiting lists",
+					"error":   err.Error(),
+				})
+			return
+		}
+		ctx.JSON(http.StatusOK, patient)
+	case db_service.ErrNotFound:
+		ctx.JSON(
+			http.StatusNotFound,
+			gin.H{
+				"status":  "Not Found",
+				"message": "Patient not found",
+				"error":   err.Error(),
+			},
+		)
+	default:
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to update patient in database",
+				"error":   err.Error(),
+			})
+	}
+}
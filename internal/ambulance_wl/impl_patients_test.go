@@ -0,0 +1,129 @@
+package ambulance_wl
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type PatientsSuite struct {
+	suite.Suite
+	ambulances db_service.DbService[Ambulance]
+	patients   db_service.DbService[Patient]
+}
+
+func TestPatientsSuite(t *testing.T) {
+	suite.Run(t, new(PatientsSuite))
+}
+
+func (suite *PatientsSuite) SetupTest() {
+	suite.ambulances = newMemoryStore(suite.T(), map[string]Ambulance{
+		"waiting": {Id: "waiting", WaitingList: []WaitingListEntry{
+			{Id: "entry", PatientId: "waiting-patient", Name: "Old Name", WaitingSince: time.Now()},
+		}},
+		"scheduled": {Id: "scheduled", Schedules: []Schedule{
+			{Id: "schedule", PatientId: "scheduled-patient", Start: time.Now()},
+		}},
+	})
+	suite.patients = newMemoryStore(suite.T(), map[string]Patient{
+		"waiting-patient":   {Id: "waiting-patient", Name: "Old Name"},
+		"scheduled-patient": {Id: "scheduled-patient", Name: "Scheduled"},
+		"unused-patient":    {Id: "unused-patient", Name: "Unused"},
+	})
+}
+
+func (suite *PatientsSuite) services() map[string]interface{} {
+	return map[string]interface{}{"db_service": suite.ambulances, "patients_db_service": suite.patients}
+}
+
+func (suite *PatientsSuite) Test_DeletePatient_BlockedWhileReferenced() {
+	sut := implPatientsAPI{}
+	for _, test := range []struct {
+		patientId string
+		status    int
+	}{
+		{"waiting-patient", http.StatusConflict},
+		{"scheduled-patient", http.StatusConflict},
+		{"unused-patient", http.StatusNoContent},
+	} {
+		suite.Run(test.patientId, func() {
+			// ARRANGE
+			ctx, recorder := newHandlerContext(suite.services(), "DELETE", "/patients/"+test.patientId, "",
+				gin.Param{Key: "patientId", Value: test.patientId})
+
+			// ACT
+			sut.DeletePatient(ctx)
+
+			// ASSERT
+			suite.Equal(test.status, recorder.Code)
+			_, err := suite.patients.FindDocument(context.Background(), test.patientId)
+			suite.Equal(test.status == http.StatusNoContent, err == db_service.ErrNotFound)
+		})
+	}
+}
+
+func (suite *PatientsSuite) Test_UpdatePatient_NameRefreshedInWaitingLists() {
+	// ARRANGE
+	ctx, recorder := newHandlerContext(suite.services(), "PUT", "/patients/waiting-patient", `{"name": "New Name"}`,
+		gin.Param{Key: "patientId", Value: "waiting-patient"})
+	sut := implPatientsAPI{}
+
+	// ACT
+	sut.UpdatePatient(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	stored, _ := suite.ambulances.FindDocument(context.Background(), "waiting")
+	suite.Equal("New Name", stored.WaitingList[0].Name)
+}
+
+func (suite *PatientsSuite) Test_Create_PatientFromRegistry() {
+	// ARRANGE
+	sut := implAmbulanceWaitingListAPI{}
+	create := func(body string) int {
+		ctx, recorder := newHandlerContext(suite.services(), "POST", "/waiting-list/scheduled/entries", body,
+			gin.Param{Key: "ambulanceId", Value: "scheduled"})
+		sut.CreateWaitingListEntry(ctx)
+		return recorder.Code
+	}
+
+	// ACT
+	unknown := create(`{"patientId": "unknown-patient", "waitingSince": "2038-12-24T10:05:00Z"}`)
+	registered := create(`{"patientId": "unused-patient", "name": "Someone Else", "waitingSince": "2038-12-24T10:05:00Z"}`)
+
+	// ASSERT
+	suite.Equal(http.StatusBadRequest, unknown)
+	suite.Equal(http.StatusOK, registered)
+	stored, _ := suite.ambulances.FindDocument(context.Background(), "scheduled")
+	suite.Require().Len(stored.WaitingList, 1)
+	suite.Equal("Unused", stored.WaitingList[0].Name)
+}
+
+func (suite *PatientsSuite) Test_Update_ChangedPatientLookedUpInRegistry() {
+	// ARRANGE
+	sut := implAmbulanceWaitingListAPI{}
+	update := func(patientId string) int {
+		ctx, recorder := newHandlerContext(suite.services(), "PUT", "/waiting-list/waiting/entries/entry",
+			`{"patientId": "`+patientId+`", "name": "Forged"}`,
+			gin.Param{Key: "ambulanceId", Value: "waiting"},
+			gin.Param{Key: "entryId", Value: "entry"})
+		sut.UpdateWaitingListEntry(ctx)
+		return recorder.Code
+	}
+
+	// ACT
+	unregistered := update("unknown-patient")
+	registered := update("unused-patient")
+
+	// ASSERT
+	suite.Equal(http.StatusBadRequest, unregistered)
+	suite.Equal(http.StatusOK, registered)
+	stored, _ := suite.ambulances.FindDocument(context.Background(), "waiting")
+	suite.Equal("unused-patient", stored.WaitingList[0].PatientId)
+	suite.Equal("Unused", stored.WaitingList[0].Name)
+}
@@ -0,0 +1,178 @@
+package ambulance_wl
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+const (
+	// refresh interval of the waiting room board
+	defaultBoardRefreshSeconds = 30
+	minBoardRefreshSeconds     = 5
+	// shown instead of the ticket of the entry which does not have one yet
+	missingTicketPlaceholder = "-"
+)
+
+//go:embed templates/public_board.html
+var publicBoardTemplateText string
+
+var publicBoardTemplate = template.Must(template.New("public_board").Funcs(template.FuncMap{
+	"clock": func(value time.Time) string {
+		if value.IsZero() {
+			return "-"
+		}
+		return value.Format("15:04")
+	},
+}).Parse(publicBoardTemplateText))
+
+// PublicDisplay is the anonymised waiting list shown on the screen in the waiting room
+type PublicDisplay struct {
+	AmbulanceName string               `json:"ambulanceName"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	Entries       []PublicDisplayEntry `json:"entries"`
+}
+
+// PublicDisplayEntry identifies the patient only by the ticket, never by the name or the patient id
+type PublicDisplayEntry struct {
+	Ticket string `json:"ticket"`
+	// one based position among the waiting patients, zero for the called patients
+	Position       int       `json:"position,omitempty"`
+	Status         string    `json:"status"`
+	EstimatedStart time.Time `json:"estimatedStart,omitempty"`
+	// room the called patient should enter
+	Room string `json:"room,omitempty"`
+}
+
+// AddPublicRoutes registers the read-only routes for the waiting room screens.
+// They are intentionally outside of the /api group, which is protected by the authentication.
+func AddPublicRoutes(engine *gin.Engine) {
+	group := engine.Group("/public")
+	group.GET("/ambulance/:ambulanceId/display", getPublicDisplay)
+	group.GET("/ambulance/:ambulanceId/board", getPublicBoard)
+}
+
+// displayTicket is the anonymous identification of the entry shown in public. The entries created
+// before the ticket numbers were introduced get them from the maintenance, the ids chosen by the
+// clients may reveal the patient so a placeholder is shown meanwhile.
+func (this *WaitingListEntry) displayTicket() string {
+	if this.TicketNumber != "" {
+		return this.TicketNumber
+	}
+	return missingTicketPlaceholder
+}
+
+// publicDisplay of the patients waiting and being called, in the local time of the ambulance
+func (this *Ambulance) publicDisplay(now time.Time) PublicDisplay {
+	location := this.Settings.location()
+	display := PublicDisplay{
+		AmbulanceName: this.Name,
+		UpdatedAt:     now.In(location),
+		Entries:       []PublicDisplayEntry{},
+	}
+	position := 0
+	for _, entry := range this.WaitingList {
+		switch {
+		case entry.status() == statusCalled:
+			room := entry.RoomId
+			if roomIndx := slices.IndexFunc(this.Rooms, func(candidate Room) bool {
+				return candidate.Id == entry.RoomId && candidate.Name != ""
+			}); roomIndx >= 0 {
+				room = this.Rooms[roomIndx].Name
+			}
+			display.Entries = append(display.Entries, PublicDisplayEntry{
+				Ticket: entry.displayTicket(),
+				Status: statusCalled,
+				Room:   room,
+			})
+		case entry.isWaiting():
+			position++
+			display.Entries = append(display.Entries, PublicDisplayEntry{
+				Ticket:         entry.displayTicket(),
+				Position:       position,
+				Status:         statusWaiting,
+				EstimatedStart: entry.EstimatedStart.In(location),
+			})
+		}
+	}
+	return display
+}
+
+// loadPublicDisplay responds with the error and returns false if the display cannot be provided
+func loadPublicDisplay(ctx *gin.Context) (PublicDisplay, bool) {
+	value, _ := ctx.Get("db_service")
+	db, ok := value.(db_service.DbService[Ambulance])
+	if !ok {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			gin.H{
+				"status":  "Internal Server Error",
+				"message": "db_service context is not of type db_service.DbService",
+			})
+		return PublicDisplay{}, false
+	}
+
+	ambulance, err := db.FindDocument(ctx, ctx.Param("ambulanceId"))
+	switch err {
+	case nil:
+	case db_service.ErrNotFound:
+		ctx.JSON(
+			http.StatusNotFound,
+			gin.H{
+				"status":  "Not Found",
+				"message": "Ambulance not found",
+			})
+		return PublicDisplay{}, false
+	default:
+		// details of the failure are not public
+		ctx.JSON(
+			http.StatusBadGateway,
+			gin.H{
+				"status":  "Bad Gateway",
+				"message": "Failed to load ambulance from database",
+			})
+		return PublicDisplay{}, false
+	}
+
+	// estimates are refreshed for the display only, the stored ambulance is not changed
+	ambulance.reconcileWaitingList(ctx.Request.Context())
+	ctx.Header("Cache-Control", "no-store")
+	return ambulance.publicDisplay(time.Now()), true
+}
+
+// getPublicDisplay - Provides the anonymised waiting list of the ambulance
+func getPublicDisplay(ctx *gin.Context) {
+	if display, ok := loadPublicDisplay(ctx); ok {
+		ctx.JSON(http.StatusOK, display)
+	}
+}
+
+// getPublicBoard - Provides the auto-refreshing HTML board with the anonymised waiting list of the ambulance
+func getPublicBoard(ctx *gin.Context) {
+	refresh, err := strconv.Atoi(ctx.DefaultQuery("refresh", strconv.Itoa(defaultBoardRefreshSeconds)))
+	if err != nil || refresh < minBoardRefreshSeconds {
+		refresh = defaultBoardRefreshSeconds
+	}
+
+	display, ok := loadPublicDisplay(ctx)
+	if !ok {
+		return
+	}
+
+	var content strings.Builder
+	if err := publicBoardTemplate.Execute(&content, gin.H{
+		"Display":        display,
+		"RefreshSeconds": refresh,
+	}); err != nil {
+		ctx.String(http.StatusInternalServerError, "Failed to render the board")
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(content.String()))
+}
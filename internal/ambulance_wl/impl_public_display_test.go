@@ -0,0 +1,51 @@
+package ambulance_wl
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type PublicDisplaySuite struct {
+	suite.Suite
+}
+
+func TestPublicDisplaySuite(t *testing.T) {
+	suite.Run(t, new(PublicDisplaySuite))
+}
+
+func (suite *PublicDisplaySuite) Test_PublicDisplay_Anonymised() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"public": {
+			Id:    "public",
+			Name:  "Ambulancia",
+			Rooms: []Room{{Id: "r1", Name: "Miestnosť 1"}},
+			WaitingList: []WaitingListEntry{
+				{Id: "waiting-entry", Name: "Jožko Púčik", PatientId: "460527-jozef-pucik", WaitingSince: time.Now()},
+				{Id: "called-entry", Name: "Ferdinand Nagy", PatientId: "780907-ferdinand-nagy", WaitingSince: time.Now(),
+					Status: statusCalled, CalledAt: time.Now(), RoomId: "r1"},
+			},
+		},
+	})
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db},
+		"GET", "/public/ambulance/public/board", "",
+		gin.Param{Key: "ambulanceId", Value: "public"},
+	)
+
+	// ACT
+	getPublicBoard(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	suite.NotContains(body, "WAITIN")
+	suite.Contains(body, "Miestnosť 1")
+	suite.NotContains(body, "Púčik")
+	suite.NotContains(body, "jozef")
+	suite.NotContains(body, "Nagy")
+}
@@ -1,15 +1,17 @@
 package ambulance_wl
 
 import (
+	"context"
 	"net/http"
 	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
 )
 
 func (this *implSchedulesAPI) CreateSchedule(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		var entry Schedule
 
 		if err := c.ShouldBindJSON(&entry); err != nil {
@@ -34,6 +36,10 @@ func (this *implSchedulesAPI) CreateSchedule(ctx *gin.Context) {
 			}, http.StatusBadRequest
 		}
 
+		if patient, response, status := registeredPatient(c, entry.PatientId); patient == nil {
+			return nil, response, status
+		}
+
 		if entry.RoomId == "" {
 			return nil, gin.H{
 				"status":  http.StatusBadRequest,
@@ -41,6 +47,17 @@ func (this *implSchedulesAPI) CreateSchedule(ctx *gin.Context) {
 			}, http.StatusBadRequest
 		}
 
+		// the room is checked against the loaded ambulance, the schedule is stored only if
+		// the ambulance was not modified since then
+		if !slices.ContainsFunc(ambulance.Rooms, func(room Room) bool {
+			return room.Id == entry.RoomId
+		}) {
+			return nil, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Room of the schedule does not exist",
+			}, http.StatusBadRequest
+		}
+
 		if entry.Start.IsZero() {
 			return nil, gin.H{
 				"status":  http.StatusBadRequest,
@@ -71,26 +88,14 @@ func (this *implSchedulesAPI) CreateSchedule(ctx *gin.Context) {
 		}
 
 		ambulance.Schedules = append(ambulance.Schedules, entry)
-		// //ambulance.reconcileWaitingList() TODO: this is not needed here, since we dont need to update other room data
-		// //entry was copied by value return reconciled value from the list
-		entryIndx := slices.IndexFunc(ambulance.Schedules, func(schedule_entry Schedule) bool {
-			return entry.Id == schedule_entry.Id
-		})
-		// if entryIndx < 0 {
-		// 	return nil, gin.H{
-		// 		"status":  http.StatusInternalServerError,
-		// 		"message": "Failed to save entry",
-		// 	}, http.StatusInternalServerError
-		// }
-
-		return ambulance, ambulance.Schedules[entryIndx], http.StatusOK
-
-		// return ambulance, entry, http.StatusOK
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.PushArrayElement(ctx, ambulance.Id, "Schedules", entry.Id, entry, ambulance.Version)
+		}, entry, http.StatusOK
 	})
 }
 
 func (this *implSchedulesAPI) DeleteSchedule(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (updatedAmbulance *Ambulance, responseContent interface{}, status int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		scheduleId := ctx.Param("scheduleId")
 
 		if scheduleId == "" {
@@ -112,8 +117,9 @@ func (this *implSchedulesAPI) DeleteSchedule(ctx *gin.Context) {
 		}
 
 		ambulance.Schedules = append(ambulance.Schedules[:scheduleIndx], ambulance.Schedules[scheduleIndx+1:]...)
-		//ambulance.reconcileWaitingList()
-		return ambulance, nil, http.StatusNoContent
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.PullArrayElement(ctx, ambulance.Id, "Schedules", scheduleId, ambulance.Version)
+		}, nil, http.StatusNoContent
 	})
 }
 
@@ -124,12 +130,12 @@ func (this *implSchedulesAPI) GetSchedules(ctx *gin.Context) {
 			result = []Schedule{}
 		}
 		// return nil ambulance - no need to update it in db
-		return ambulance, result, http.StatusOK
+		return nil, result, http.StatusOK
 	})
 }
 
 func (this *implSchedulesAPI) UpdateSchedule(ctx *gin.Context) {
-	updateAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (*Ambulance, interface{}, int) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
 		var schedule Schedule
 
 		if err := c.ShouldBindJSON(&schedule); err != nil {
@@ -160,15 +166,29 @@ func (this *implSchedulesAPI) UpdateSchedule(ctx *gin.Context) {
 			}, http.StatusNotFound
 		}
 
-		if schedule.Id != "" {
-			ambulance.Schedules[scheduleIdx].Id = schedule.Id
+		if schedule.Id != "" && schedule.Id != scheduleId {
+			return nil, gin.H{
+				"status":  http.StatusForbidden,
+				"message": "Value of the entryID and the data id is mismatching",
+			}, http.StatusForbidden
 		}
 
 		if schedule.RoomId != "" {
+			if !slices.ContainsFunc(ambulance.Rooms, func(room Room) bool {
+				return room.Id == schedule.RoomId
+			}) {
+				return nil, gin.H{
+					"status":  http.StatusBadRequest,
+					"message": "Room of the schedule does not exist",
+				}, http.StatusBadRequest
+			}
 			ambulance.Schedules[scheduleIdx].RoomId = schedule.RoomId
 		}
 
-		if schedule.PatientId != "" {
+		if schedule.PatientId != "" && schedule.PatientId != ambulance.Schedules[scheduleIdx].PatientId {
+			if patient, response, status := registeredPatient(c, schedule.PatientId); patient == nil {
+				return nil, response, status
+			}
 			ambulance.Schedules[scheduleIdx].PatientId = schedule.PatientId
 		}
 
@@ -184,7 +204,9 @@ func (this *implSchedulesAPI) UpdateSchedule(ctx *gin.Context) {
 			ambulance.Schedules[scheduleIdx].End = schedule.End
 		}
 
-		//ambulance.reconcileWaitingList()
-		return ambulance, ambulance.Schedules[scheduleIdx], http.StatusOK
+		updatedSchedule := ambulance.Schedules[scheduleIdx]
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			return db.SetArrayElement(ctx, ambulance.Id, "Schedules", scheduleId, updatedSchedule, ambulance.Version)
+		}, updatedSchedule, http.StatusOK
 	})
 }
@@ -0,0 +1,86 @@
+package ambulance_wl
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type SchedulesSuite struct {
+	suite.Suite
+}
+
+func TestSchedulesSuite(t *testing.T) {
+	suite.Run(t, new(SchedulesSuite))
+}
+
+// concurrentlyChangedDb applies the change right after the first snapshot of the ambulance is loaded
+type concurrentlyChangedDb struct {
+	db_service.DbService[Ambulance]
+	change func()
+}
+
+func (this *concurrentlyChangedDb) FindDocument(ctx context.Context, id string) (*Ambulance, error) {
+	ambulance, err := this.DbService.FindDocument(ctx, id)
+	if this.change != nil {
+		this.change()
+		this.change = nil
+	}
+	return ambulance, err
+}
+
+func (suite *SchedulesSuite) Test_CreateSchedule_RoomDeletedConcurrently() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"schedules": {Id: "schedules", Rooms: []Room{{Id: "room"}}},
+	})
+	patients := newMemoryStore(suite.T(), map[string]Patient{
+		"patient": {Id: "patient", Name: "Patient"},
+	})
+	changed := &concurrentlyChangedDb{DbService: db, change: func() {
+		suite.Require().NoError(db.PullArrayElement(context.Background(), "schedules", "Rooms", "room", 0))
+	}}
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db_service.DbService[Ambulance](changed), "patients_db_service": patients},
+		"POST", "/schedules/schedules/entries",
+		`{"id": "schedule", "patientId": "patient", "roomId": "room", "start": "`+time.Now().Format(time.RFC3339)+`"}`,
+		gin.Param{Key: "ambulanceId", Value: "schedules"},
+	)
+	sut := implSchedulesAPI{}
+
+	// ACT
+	sut.CreateSchedule(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusBadRequest, recorder.Code)
+	suite.Contains(recorder.Body.String(), "Room of the schedule does not exist")
+	stored, _ := db.FindDocument(context.Background(), "schedules")
+	suite.Empty(stored.Schedules)
+}
+
+func (suite *SchedulesSuite) Test_UpdateSchedule_IdIsImmutable() {
+	// ARRANGE
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"schedules": {Id: "schedules", Schedules: []Schedule{{Id: "first"}, {Id: "second"}}},
+	})
+	ctx, recorder := newHandlerContext(
+		map[string]interface{}{"db_service": db},
+		"PUT", "/schedules/schedules/entries/first", `{"id": "second", "note": "renamed"}`,
+		gin.Param{Key: "ambulanceId", Value: "schedules"},
+		gin.Param{Key: "scheduleId", Value: "first"},
+	)
+	sut := implSchedulesAPI{}
+
+	// ACT
+	sut.UpdateSchedule(ctx)
+
+	// ASSERT
+	suite.Equal(http.StatusForbidden, recorder.Code)
+	stored, _ := db.FindDocument(context.Background(), "schedules")
+	suite.Equal([]Schedule{{Id: "first"}, {Id: "second"}}, stored.Schedules)
+}
@@ -27,4 +27,11 @@ type Ambulance struct {
 	PredefinedConditions []Condition `json:"predefinedConditions,omitempty"`
 
 	Schedules []Schedule `json:"schedules,omitempty"`
+
+	Settings AmbulanceSettings `json:"settings,omitempty"`
+
+	TicketCounter TicketCounter `json:"ticketCounter,omitempty"`
+
+	// Revision of the ambulance document, incremented on each update. Used for optimistic concurrency control.
+	Version int64 `json:"version,omitempty"`
 }
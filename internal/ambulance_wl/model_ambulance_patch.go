@@ -0,0 +1,23 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// AmbulancePatch - Properties of the ambulance to be changed. Omitted properties are kept unchanged.
+type AmbulancePatch struct {
+
+	// Human readable display name of the ambulance
+	Name string `json:"name,omitempty"`
+
+	RoomNumber string `json:"roomNumber,omitempty"`
+
+	// Expected revision of the ambulance. If provided, the update is rejected when the ambulance was modified in between.
+	Version int64 `json:"version,omitempty"`
+}
@@ -0,0 +1,39 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// AmbulanceSettings - Operational settings of the ambulance used when estimating the waiting times
+type AmbulanceSettings struct {
+
+	// IANA time zone of the opening hours
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Intervals when the ambulance is open. Ambulance without opening hours is considered to be always open.
+	OpeningHours []TimeInterval `json:"openingHours,omitempty"`
+
+	// Intervals within the opening hours when patients are not served
+	Breaks []TimeInterval `json:"breaks,omitempty"`
+
+	// Duration of the visit used when neither the entry nor its predefined condition provide one
+	DefaultVisitDurationMinutes int32 `json:"defaultVisitDurationMinutes,omitempty"`
+
+	// Prefix of the ticket numbers assigned to the new entries
+	TicketPrefix string `json:"ticketPrefix,omitempty"`
+
+	// Minutes after which the called patient not showing up is marked as no-show, counted from the call or the estimated start of the visit, whichever is later. Entries still waiting or in treatment expire the same time after the closing time of the ambulance. Default is 30 minutes.
+	ExpiryGraceMinutes int32 `json:"expiryGraceMinutes,omitempty"`
+
+	// Minutes after which the entries still waiting or in treatment expire if the ambulance has no opening hours, counted from the arrival of the waiting patient or the start of the treatment. Default is 720 minutes.
+	StaleEntryMinutes int32 `json:"staleEntryMinutes,omitempty"`
+
+	// Minimal change of the estimated start of the waiting entry, found by the periodic re-estimation, which is announced to the subscribers of the waiting list events. Default is 5 minutes.
+	EstimateShiftThresholdMinutes int32 `json:"estimateShiftThresholdMinutes,omitempty"`
+}
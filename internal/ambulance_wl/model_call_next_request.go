@@ -0,0 +1,21 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// CallNextRequest - Constraints of the room calling the next patient. All properties are optional.
+type CallNextRequest struct {
+
+	// Id of the ambulance room the patient is called into. The patients assigned or scheduled to the room are preferred, the patients of the other rooms are skipped.
+	RoomId string `json:"roomId,omitempty"`
+
+	// If not empty, only patients with one of these condition codes are called
+	ConditionCodes []string `json:"conditionCodes,omitempty"`
+}
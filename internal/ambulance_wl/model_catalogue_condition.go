@@ -0,0 +1,33 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// CatalogueCondition - Condition of the hospital-wide catalogue referenced by the codes of the ambulance conditions
+type CatalogueCondition struct {
+
+	// Key of the condition in the catalogue, the code system and the code joined by a colon
+	Id string `json:"id"`
+
+	// Code system the code belongs to
+	System string `json:"system"`
+
+	// Code of the condition in its code system
+	Code string `json:"code"`
+
+	// Display name of the condition
+	Value string `json:"value"`
+
+	// Link to encyclopedical explanation of the condition
+	Reference string `json:"reference,omitempty"`
+
+	// Typical duration of the visit used by the ambulances not overriding it
+	TypicalDurationMinutes int32 `json:"typicalDurationMinutes,omitempty"`
+}
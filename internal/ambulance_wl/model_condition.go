@@ -13,10 +13,15 @@ package ambulance_wl
 // Condition - Describes disease, symptoms, or other reasons of patient   visit
 type Condition struct {
 
-	Value string `json:"value"`
+	// Required unless the code refers to the condition catalogue, the properties not set are taken from the catalogue
+	Value string `json:"value,omitempty"`
 
+	// Code of the condition in the hospital-wide catalogue
 	Code string `json:"code,omitempty"`
 
+	// Code system of the code, required only if the same code is in the catalogue for several systems
+	System string `json:"system,omitempty"`
+
 	// Link to encyclopedical explanation of the patient's condition
 	Reference string `json:"reference,omitempty"`
 
@@ -0,0 +1,39 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// DurationStatistics - Configured and learned visit durations of one condition in the ambulance
+type DurationStatistics struct {
+
+	ConditionCode string `json:"conditionCode"`
+
+	ConditionValue string `json:"conditionValue,omitempty"`
+
+	// Duration configured as the typical duration of the predefined condition or the default visit duration of the ambulance
+	ConfiguredMinutes int32 `json:"configuredMinutes"`
+
+	// Median duration of the recently completed visits, provided only if there are enough such visits
+	LearnedMinutes int32 `json:"learnedMinutes,omitempty"`
+
+	// Duration used as the estimate for new waiting list entries with this condition
+	DefaultMinutes int32 `json:"defaultMinutes"`
+
+	// Number of recently completed visits with this condition
+	VisitCount int32 `json:"visitCount"`
+
+	Percentile25Minutes int32 `json:"percentile25Minutes,omitempty"`
+
+	MedianMinutes int32 `json:"medianMinutes,omitempty"`
+
+	Percentile75Minutes int32 `json:"percentile75Minutes,omitempty"`
+
+	Percentile90Minutes int32 `json:"percentile90Minutes,omitempty"`
+}
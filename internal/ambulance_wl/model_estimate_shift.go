@@ -0,0 +1,34 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+import (
+	"time"
+)
+
+// EstimateShift - Change of the estimated start of the waiting entry found by the periodic re-estimation
+type EstimateShift struct {
+
+	// Id of the entry in the waiting list
+	EntryId string `json:"entryId"`
+
+	// Ticket number of the entry
+	TicketNumber string `json:"ticketNumber,omitempty"`
+
+	// Estimated start of the visit announced before the re-estimation
+	PreviousEstimatedStart time.Time `json:"previousEstimatedStart,omitempty"`
+
+	// New estimated start of the visit
+	EstimatedStart time.Time `json:"estimatedStart"`
+
+	// Change of the estimate in minutes, negative if the visit starts earlier
+	ShiftMinutes int32 `json:"shiftMinutes,omitempty"`
+}
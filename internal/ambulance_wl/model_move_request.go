@@ -0,0 +1,30 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// MoveRequest - New position of the entry in the waiting list. At most one of beforeEntryId, afterEntryId, and position may be provided, if none is provided the manual override is removed.
+type MoveRequest struct {
+
+	// Id of the entry the moved entry is placed before
+	BeforeEntryId string `json:"beforeEntryId,omitempty"`
+
+	// Id of the entry the moved entry is placed after
+	AfterEntryId string `json:"afterEntryId,omitempty"`
+
+	// One based position among the waiting entries
+	Position int32 `json:"position,omitempty"`
+
+	// Who moves the entry, required when moving
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// Clinical or other reason of the change, required when moving
+	Reason string `json:"reason,omitempty"`
+}
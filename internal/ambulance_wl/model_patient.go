@@ -0,0 +1,29 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// Patient - Patient registered in the hospital
+type Patient struct {
+
+	// Unique identifier of the patient known to Web-In-Cloud system
+	Id string `json:"id"`
+
+	// Full name of the patient shown in the waiting lists
+	Name string `json:"name"`
+
+	// Date of birth of the patient
+	BirthDate string `json:"birthDate,omitempty"`
+
+	// Administrative gender of the patient
+	Gender string `json:"gender,omitempty"`
+
+	Contact PatientContact `json:"contact,omitempty"`
+}
@@ -0,0 +1,24 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// PatientContact - Contact information of the patient
+type PatientContact struct {
+
+	// Phone number of the patient
+	Phone string `json:"phone,omitempty"`
+
+	// E-mail address of the patient
+	Email string `json:"email,omitempty"`
+
+	// Postal address of the patient
+	Address string `json:"address,omitempty"`
+}
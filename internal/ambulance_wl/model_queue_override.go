@@ -0,0 +1,34 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+import (
+	"time"
+)
+
+// QueueOverride - Manual change of the position of the entry in the waiting list. While it is present the entry is not subject to the priority aging.
+type QueueOverride struct {
+
+	// Triage level used to order the entry instead of its aged priority
+	Priority int32 `json:"priority"`
+
+	// Timestamp used to order the entry among the entries with the same priority
+	QueuedSince time.Time `json:"queuedSince"`
+
+	// Who moved the entry
+	ChangedBy string `json:"changedBy"`
+
+	// Clinical or other reason of the change
+	Reason string `json:"reason"`
+
+	// Timestamp of the change
+	ChangedAt time.Time `json:"changedAt"`
+}
@@ -0,0 +1,21 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// TicketCounter - Last ticket number assigned by the ambulance. Read only.
+type TicketCounter struct {
+
+	// Day in the time zone of the ambulance, the numbering restarts every day
+	Day string `json:"day,omitempty"`
+
+	// Last number assigned on the day
+	Last int32 `json:"last,omitempty"`
+}
@@ -0,0 +1,24 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// TimeInterval - Recurring interval within a day of the week
+type TimeInterval struct {
+
+	// Day of the week the interval applies to. If not provided, the interval applies to every day.
+	Day string `json:"day,omitempty"`
+
+	// Local start time of the interval in HH:MM format
+	From string `json:"from"`
+
+	// Local end time of the interval in HH:MM format
+	Until string `json:"until"`
+}
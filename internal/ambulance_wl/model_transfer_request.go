@@ -0,0 +1,21 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+// TransferRequest - Target of the transfer of the waiting patient
+type TransferRequest struct {
+
+	// Id of the ambulance the patient is transferred to
+	TargetAmbulanceId string `json:"targetAmbulanceId"`
+
+	// If true, the patient keeps the position given by the original waitingSince, otherwise joins the target waiting list as a new arrival
+	PreservePosition bool `json:"preservePosition,omitempty"`
+}
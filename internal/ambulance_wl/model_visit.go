@@ -0,0 +1,81 @@
+/*
+ * Waiting List Api
+ *
+ * Ambulance Waiting List management for Web-In-Cloud system
+ *
+ * API version: 1.0.0
+ * Contact: test@test.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package ambulance_wl
+
+import (
+	"time"
+)
+
+// Visit - Archived record of the patient visit which left the waiting list
+type Visit struct {
+
+	// Unique id of the visit
+	Id string `json:"id"`
+
+	// Id of the waiting list entry of the visit, the entry ids may be reused by later visits
+	EntryId string `json:"entryId,omitempty"`
+
+	// Id of the ambulance where the visit took place
+	AmbulanceId string `json:"ambulanceId"`
+
+	// Unique identifier of the patient known to Web-In-Cloud system
+	PatientId string `json:"patientId"`
+
+	// Ticket number assigned to the waiting list entry
+	TicketNumber string `json:"ticketNumber,omitempty"`
+
+	// Name of patient in waiting list
+	Name string `json:"name,omitempty"`
+
+	Condition Condition `json:"condition,omitempty"`
+
+	// Triage level of the patient on the five-level Emergency Severity Index scale
+	Priority int32 `json:"priority,omitempty"`
+
+	// Final state of the visit, removed if the entry was deleted from the waiting list before the treatment, expired if the entry was still waiting or in treatment after the closing time of the ambulance
+	Status string `json:"status"`
+
+	// Id of the ambulance room the patient was called into
+	RoomId string `json:"roomId,omitempty"`
+
+	// Timestamp since when the patient entered the waiting list
+	WaitingSince time.Time `json:"waitingSince"`
+
+	// Last estimated time of entering ambulance
+	EstimatedStart time.Time `json:"estimatedStart,omitempty"`
+
+	// Last estimated duration of ambulance visit
+	EstimatedDurationMinutes int32 `json:"estimatedDurationMinutes,omitempty"`
+
+	// Timestamp when the patient was called into the ambulance
+	CalledAt time.Time `json:"calledAt,omitempty"`
+
+	// Timestamp when the treatment of the patient started
+	ActualStart time.Time `json:"actualStart,omitempty"`
+
+	// Timestamp when the treatment of the patient was completed
+	ActualEnd time.Time `json:"actualEnd,omitempty"`
+
+	// Timestamp when the patient was marked as not showing up
+	NoShowAt time.Time `json:"noShowAt,omitempty"`
+
+	// Timestamp when the visit was archived
+	ArchivedAt time.Time `json:"archivedAt"`
+
+	// Actual waiting time of the patient, from entering the waiting list until the start of the treatment
+	WaitingMinutes int32 `json:"waitingMinutes,omitempty"`
+
+	// Actual duration of the treatment
+	TreatmentMinutes int32 `json:"treatmentMinutes,omitempty"`
+
+	// Difference between the actual and the estimated start of the treatment, positive if the patient waited longer than estimated
+	StartDelayMinutes int32 `json:"startDelayMinutes,omitempty"`
+}
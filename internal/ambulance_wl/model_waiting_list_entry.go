@@ -25,14 +25,55 @@ type WaitingListEntry struct {
 	// Unique identifier of the patient known to Web-In-Cloud system
 	PatientId string `json:"patientId"`
 
+	// Human readable ticket number assigned when the entry is created, unique within the ambulance and the day. Read only.
+	TicketNumber string `json:"ticketNumber,omitempty"`
+
+	// Day the ticket number was issued on in the time zone of the ambulance, the numbers restart every day. Read only.
+	TicketDay string `json:"ticketDay,omitempty"`
+
 	// Timestamp since when the patient entered the waiting list
 	WaitingSince time.Time `json:"waitingSince"`
 
 	// Estimated time of entering ambulance. Ignored on post.
 	EstimatedStart time.Time `json:"estimatedStart,omitempty"`
 
+	// Estimated time of entering ambulance last announced to the subscribers of the waiting list, the shifts of the estimate are measured from it. Read only.
+	AnnouncedEstimatedStart time.Time `json:"announcedEstimatedStart,omitempty"`
+
 	// Estimated duration of ambulance visit. If not provided then it will be computed based on condition and ambulance settings
-	EstimatedDurationMinutes int32 `json:"estimatedDurationMinutes"`
+	EstimatedDurationMinutes int32 `json:"estimatedDurationMinutes,omitempty"`
 
 	Condition Condition `json:"condition,omitempty"`
+
+	// Triage level of the patient on the five-level Emergency Severity Index scale, 1 is the most urgent. Defaults to 3 if not provided.
+	Priority int32 `json:"priority,omitempty"`
+
+	// State of the patient visit. Ignored on post and put, use the transition operations to change it.
+	Status string `json:"status,omitempty"`
+
+	// Timestamp when the patient was called into the ambulance
+	CalledAt time.Time `json:"calledAt,omitempty"`
+
+	// Id of the ambulance room the patient was called into
+	RoomId string `json:"roomId,omitempty"`
+
+	// Timestamp when the treatment of the patient started
+	ActualStart time.Time `json:"actualStart,omitempty"`
+
+	// Timestamp when the treatment of the patient was completed
+	ActualEnd time.Time `json:"actualEnd,omitempty"`
+
+	// Timestamp when the patient was marked as not showing up
+	NoShowAt time.Time `json:"noShowAt,omitempty"`
+
+	// Timestamp determining the position in the queue if it differs from waitingSince, e.g. after the transfer from another ambulance
+	QueuedSince time.Time `json:"queuedSince,omitempty"`
+
+	// Id of the ambulance the patient was transferred from
+	TransferredFrom string `json:"transferredFrom,omitempty"`
+
+	// Timestamp when the patient was transferred from another ambulance
+	TransferredAt time.Time `json:"transferredAt,omitempty"`
+
+	QueueOverride QueueOverride `json:"queueOverride,omitempty"`
 }
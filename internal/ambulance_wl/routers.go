@@ -27,6 +27,11 @@ func AddRoutes(engine *gin.Engine) {
     api.addRoutes(group)
   }
   
+  {
+    api := newAmbulanceVisitsAPI()
+    api.addRoutes(group)
+  }
+  
   {
     api := newAmbulanceWaitingListAPI()
     api.addRoutes(group)
@@ -37,6 +42,21 @@ func AddRoutes(engine *gin.Engine) {
     api.addRoutes(group)
   }
   
+  {
+    api := newConditionCatalogueAPI()
+    api.addRoutes(group)
+  }
+  
+  {
+    api := newFhirAPI()
+    api.addRoutes(group)
+  }
+  
+  {
+    api := newPatientsAPI()
+    api.addRoutes(group)
+  }
+  
   {
     api := newSchedulesAPI()
     api.addRoutes(group)
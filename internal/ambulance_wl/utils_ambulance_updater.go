@@ -1,8 +1,10 @@
 package ambulance_wl
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -38,12 +41,135 @@ func init() {
 	}
 }
 
+// number of attempts to apply the updater before giving up on concurrent modifications
+const maxUpdateAttempts = 5
+
 type ambulanceUpdater = func(
 	ctx *gin.Context,
 	ambulance *Ambulance,
 ) (updatedAmbulance *Ambulance, responseContent interface{}, status int)
 
+// ambulancePatch persists a targeted change of the ambulance document
+type ambulancePatch = func(ctx context.Context, db db_service.DbService[Ambulance]) error
+
+// ambulancePatcher is an alternative to the ambulanceUpdater that persists only the changed parts
+// of the ambulance. The patcher applies the change also to the provided ambulance so that it reflects
+// the stored state. Nil patch means there is nothing to store.
+type ambulancePatcher = func(
+	ctx *gin.Context,
+	ambulance *Ambulance,
+) (patch ambulancePatch, responseContent interface{}, status int)
+
+// waitingListPatch stores the reconciled waiting list of the ambulance in a single atomic update,
+// the update fails with db_service.ErrVersionMismatch if the ambulance was modified in between.
+// The whole list is written, the reconciliation reorders and re-estimates all its entries.
+func waitingListPatch(ambulance *Ambulance) ambulancePatch {
+	waitingList := ambulance.WaitingList
+	if waitingList == nil {
+		waitingList = []WaitingListEntry{}
+	}
+	return propertyPatch(ambulance, "WaitingList", waitingList)
+}
+
+// ticketedWaitingListPatch stores the reconciled waiting list together with the ticket counter
+// of the ambulance in a single atomic update, if the tickets were issued to its entries
+func ticketedWaitingListPatch(ambulance *Ambulance) ambulancePatch {
+	version := ambulance.Version
+	return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+		properties := map[string]interface{}{
+			"WaitingList":   ambulance.withEmptyArrays().WaitingList,
+			"TicketCounter": ambulance.TicketCounter,
+		}
+		if err := db.SetProperties(ctx, ambulance.Id, properties, version); err != nil {
+			return err
+		}
+		ambulance.Version = version + 1
+		return nil
+	}
+}
+
+// predefinedConditionsPatch stores the predefined conditions of the ambulance in a single atomic update,
+// the update fails with db_service.ErrVersionMismatch if the ambulance was modified in between
+func predefinedConditionsPatch(ambulance *Ambulance) ambulancePatch {
+	conditions := ambulance.PredefinedConditions
+	if conditions == nil {
+		conditions = []Condition{}
+	}
+	return propertyPatch(ambulance, "PredefinedConditions", conditions)
+}
+
+func propertyPatch(ambulance *Ambulance, property string, value interface{}) ambulancePatch {
+	version := ambulance.Version
+	return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+		if err := db.SetProperty(ctx, ambulance.Id, property, value, version); err != nil {
+			return err
+		}
+		ambulance.Version = version + 1
+		return nil
+	}
+}
+
+// changeWaitingList applies the change to the waiting list and other properties of the ambulance other than
+// the one of the request, the change is re-applied if the ambulance was modified concurrently
+func changeWaitingList(
+	ctx context.Context,
+	db db_service.DbService[Ambulance],
+	ambulanceId string,
+	change func(ambulance *Ambulance) error,
+) (*Ambulance, error) {
+	var err error
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		var ambulance *Ambulance
+		if ambulance, err = db.FindDocument(ctx, ambulanceId); err != nil {
+			return nil, err
+		}
+		if err = change(ambulance); err != nil {
+			return nil, err
+		}
+		ambulance.reconcileWaitingList(ctx)
+		switch err = ticketedWaitingListPatch(ambulance)(ctx, db); err {
+		case nil:
+			return ambulance, nil
+		case db_service.ErrVersionMismatch:
+			// reload and try again
+		default:
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// archiveVisitPatch stores the visit in the archive before applying the patch. The visit is
+// archived first so that it is never lost, if the patch fails it is overwritten on the next attempt,
+// which therefore must archive the visit with the same id.
+func archiveVisitPatch(visits db_service.DbService[Visit], visit *Visit, patch ambulancePatch) ambulancePatch {
+	return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+		err := visits.CreateDocument(ctx, visit.Id, visit)
+		if err == db_service.ErrConflict {
+			err = visits.UpdateDocument(ctx, visit.Id, visit)
+		}
+		if err != nil {
+			return err
+		}
+		return patch(ctx, db)
+	}
+}
+
 func updateAmbulanceFunc(ctx *gin.Context, updater ambulanceUpdater) {
+	patchAmbulanceFunc(ctx, func(c *gin.Context, ambulance *Ambulance) (ambulancePatch, interface{}, int) {
+		updatedAmbulance, responseObject, status := updater(c, ambulance)
+		if updatedAmbulance == nil {
+			return nil, responseObject, status
+		}
+		return func(ctx context.Context, db db_service.DbService[Ambulance]) error {
+			err := db.UpdateDocument(ctx, c.Param("ambulanceId"), updatedAmbulance)
+			*ambulance = *updatedAmbulance
+			return err
+		}, responseObject, status
+	})
+}
+
+func patchAmbulanceFunc(ctx *gin.Context, patcher ambulancePatcher) {
 	// special handling for gin context
 	// we need to extract the span context and create a new context to ensure span context propagation
 	// to the updater function
@@ -77,69 +203,95 @@ func updateAmbulanceFunc(ctx *gin.Context, updater ambulanceUpdater) {
 
 	ambulanceId := ctx.Param("ambulanceId")
 
-	span.AddEvent("updateAmbulanceFunc: finding document in database")
-	start := time.Now()
-	ambulance, err := db.FindDocument(spanctx, ambulanceId)
-	dbTimeSpent.Add(ctx, float64(float64(time.Since(start)))/float64(time.Millisecond), metric.WithAttributes(
-		attribute.String("operation", "find"),
-		attribute.String("ambulance_id", ambulanceId),
-		attribute.String("ambulance_name", ambulance.Name),
-	))
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
+	// the request body is consumed by the updater, keep a copy so that
+	// the updater can be re-run when the document was changed concurrently
+	var body []byte
+	if ctx.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(ctx.Request.Body); err != nil {
+			ctx.JSON(
+				http.StatusBadRequest,
+				gin.H{
+					"status":  "Bad Request",
+					"message": "Failed to read request body",
+					"error":   err.Error(),
+				})
+			return
+		}
 	}
 
-	switch err {
-	case nil:
-		// continue
-	case db_service.ErrNotFound:
-		ctx.JSON(
-			http.StatusNotFound,
-			gin.H{
-				"status":  "Not Found",
-				"message": "Ambulance not found",
-				"error":   err.Error(),
-			},
-		)
-		return
-	default:
-		ctx.JSON(
-			http.StatusBadGateway,
-			gin.H{
-				"status":  "Bad Gateway",
-				"message": "Failed to load ambulance from database",
-				"error":   err.Error(),
-			})
-		return
-	}
+	var ambulance *Ambulance
+	var patch ambulancePatch
+	var responseObject interface{}
+	var status int
+	var err error
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
 
-	if !ok {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			gin.H{
-				"status":  "Internal Server Error",
-				"message": "Failed to cast ambulance from database",
-				"error":   "Failed to cast ambulance from database",
-			})
-		return
-	}
+		span.AddEvent("updateAmbulanceFunc: finding document in database")
+		start := time.Now()
+		ambulance, err = db.FindDocument(spanctx, ambulanceId)
+		dbTimeSpent.Add(ctx, float64(float64(time.Since(start)))/float64(time.Millisecond), metric.WithAttributes(
+			attribute.String("operation", "find"),
+			attribute.String("ambulance_id", ambulanceId),
+		))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
 
-	updatedAmbulance, responseObject, status := updater(ctx, ambulance)
+		switch err {
+		case nil:
+			// continue
+		case db_service.ErrNotFound:
+			ctx.JSON(
+				http.StatusNotFound,
+				gin.H{
+					"status":  "Not Found",
+					"message": "Ambulance not found",
+					"error":   err.Error(),
+				},
+			)
+			return
+		default:
+			ctx.JSON(
+				http.StatusBadGateway,
+				gin.H{
+					"status":  "Bad Gateway",
+					"message": "Failed to load ambulance from database",
+					"error":   err.Error(),
+				})
+			return
+		}
+
+		patch, responseObject, status = patcher(ctx, ambulance)
+		if patch == nil {
+			err = nil // redundant but for clarity
+			break
+		}
 
-	if updatedAmbulance != nil {
 		span.AddEvent("updateAmbulanceFunc: updating ambulance in database")
-		start := time.Now()
-		err = db.UpdateDocument(spanctx, ambulanceId, updatedAmbulance)
+		start = time.Now()
+		err = patch(spanctx, db)
 		// update metrics
 		dbTimeSpent.Add(ctx, float64(float64(time.Since(start)))/float64(time.Millisecond), metric.WithAttributes(
 			attribute.String("operation", "update"),
 			attribute.String("ambulance_id", ambulanceId),
 			attribute.String("ambulance_name", ambulance.Name),
 		))
-		if err != nil {
-			span.SetStatus(codes.Error, err.Error())
+		if err != db_service.ErrVersionMismatch {
+			break
 		}
+		// somebody else updated the ambulance in between, reload it and apply the changes again
+		span.AddEvent("updateAmbulanceFunc: version mismatch, retrying", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+		))
+	}
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
 
+	if patch != nil && err == nil {
 		// demonstration of possible handling of async instruments:
 		// not really an operational metric, it would be more of a business metric/KPI.
 		// also UpDownCounter may be of better use in practical cases.
@@ -167,9 +319,9 @@ func updateAmbulanceFunc(ctx *gin.Context, updater ambulanceUpdater) {
 		}
 
 		// set the gauge snapshot
-		waitingListLength[ambulanceId] = int64(len(updatedAmbulance.WaitingList))
-	} else {
-		err = nil // redundant but for clarity
+		waitingListLength[ambulanceId] = int64(ambulance.waitingCount())
+
+		publishWaitingList(ctx, ambulance)
 	}
 
 	switch err {
@@ -184,7 +336,25 @@ func updateAmbulanceFunc(ctx *gin.Context, updater ambulanceUpdater) {
 			http.StatusNotFound,
 			gin.H{
 				"status":  "Not Found",
-				"message": "Ambulance was deleted while processing the request",
+				"message": "Ambulance or its entry was deleted while processing the request",
+				"error":   err.Error(),
+			},
+		)
+	case db_service.ErrConflict:
+		ctx.JSON(
+			http.StatusConflict,
+			gin.H{
+				"status":  "Conflict",
+				"message": "Entry already exists",
+				"error":   err.Error(),
+			},
+		)
+	case db_service.ErrVersionMismatch:
+		ctx.JSON(
+			http.StatusConflict,
+			gin.H{
+				"status":  "Conflict",
+				"message": "Ambulance was concurrently modified, please retry the request",
 				"error":   err.Error(),
 			},
 		)
@@ -0,0 +1,139 @@
+package ambulance_wl
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CatalogueCsvFormat describes the layout of the code list imported into the condition catalogue.
+// Columns are looked up in the header case insensitively, the empty column names are guessed
+// from the usual names of the ICD-10 and SNOMED CT distributions.
+type CatalogueCsvFormat struct {
+	// code system of all imported conditions, one of icd-10, snomed-ct, local
+	System string
+	// field delimiter, comma if not set; SNOMED CT release files are tab separated
+	Comma           rune
+	CodeColumn      string
+	ValueColumn     string
+	ReferenceColumn string
+	DurationColumn  string
+}
+
+// usual names of the columns, the first present one is used
+var (
+	catalogueCodeColumns      = []string{"code", "conceptid", "id"}
+	catalogueValueColumns     = []string{"value", "display", "term", "description", "name"}
+	catalogueReferenceColumns = []string{"reference", "url"}
+	catalogueDurationColumns  = []string{"typicaldurationminutes", "duration"}
+)
+
+// ReadConditionCatalogue parses the code list into catalogue conditions. Rows without a code
+// and rows marked as inactive (column active equal to 0, as in SNOMED CT) are skipped. If the
+// code is listed several times, e.g. synonyms of a SNOMED CT concept, the first row is used.
+func ReadConditionCatalogue(reader io.Reader, format CatalogueCsvFormat) ([]CatalogueCondition, error) {
+	if !slices.Contains(catalogueSystems, format.System) {
+		return nil, fmt.Errorf("code system must be one of %v", catalogueSystems)
+	}
+
+	csvReader := csv.NewReader(reader)
+	if format.Comma != 0 {
+		csvReader.Comma = format.Comma
+	}
+	// SNOMED CT terms contain unescaped quotes
+	csvReader.LazyQuotes = true
+	csvReader.ReuseRecord = true
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("code list is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// byte order mark of the files exported from spreadsheets
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(configured string, usual []string) int {
+		if configured != "" {
+			usual = []string{configured}
+		}
+		for _, name := range usual {
+			if i, ok := columns[strings.ToLower(name)]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	codeColumn := column(format.CodeColumn, catalogueCodeColumns)
+	valueColumn := column(format.ValueColumn, catalogueValueColumns)
+	if codeColumn < 0 || valueColumn < 0 {
+		return nil, fmt.Errorf("code list must have the code and value columns, found %v", header)
+	}
+	referenceColumn := column(format.ReferenceColumn, catalogueReferenceColumns)
+	durationColumn := column(format.DurationColumn, catalogueDurationColumns)
+	activeColumn := column("", []string{"active"})
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	conditions := []CatalogueCondition{}
+	seen := map[string]bool{}
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		if field(record, activeColumn) == "0" {
+			continue
+		}
+		code := field(record, codeColumn)
+		if code == "" || seen[code] {
+			continue
+		}
+		condition := CatalogueCondition{
+			Id:        catalogueConditionId(format.System, code),
+			System:    format.System,
+			Code:      code,
+			Value:     field(record, valueColumn),
+			Reference: field(record, referenceColumn),
+		}
+		if duration := field(record, durationColumn); duration != "" {
+			minutes, err := strconv.ParseInt(duration, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: typical duration %v is not a number of minutes", line, duration)
+			}
+			condition.TypicalDurationMinutes = int32(minutes)
+		}
+		// same rules as for the conditions of the ambulances
+		asCondition := Condition{
+			Code:                   condition.Code,
+			Value:                  condition.Value,
+			Reference:              condition.Reference,
+			TypicalDurationMinutes: condition.TypicalDurationMinutes,
+		}
+		if err := asCondition.validate(); err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+		seen[code] = true
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
@@ -0,0 +1,107 @@
+package ambulance_wl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ConditionCatalogueCsvSuite struct {
+	suite.Suite
+}
+
+func TestConditionCatalogueCsvSuite(t *testing.T) {
+	suite.Run(t, new(ConditionCatalogueCsvSuite))
+}
+
+func (suite *ConditionCatalogueCsvSuite) Test_ReadConditionCatalogue() {
+	icd10 := CatalogueCsvFormat{System: "icd-10"}
+	tests := []struct {
+		name     string
+		input    string
+		format   CatalogueCsvFormat
+		expected []CatalogueCondition
+		err      string
+	}{
+		{
+			name:   "detects code and value columns",
+			input:  "Code,Value,Reference,Duration\nJ00,Acute nasopharyngitis,https://icd.who.int/J00,15\n",
+			format: icd10,
+			expected: []CatalogueCondition{{
+				Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis",
+				Reference: "https://icd.who.int/J00", TypicalDurationMinutes: 15,
+			}},
+		},
+		{
+			name:     "detects snomed ct release columns",
+			input:    "id\tactive\tconceptId\tterm\n1\t1\t82272006\tCommon cold\n",
+			format:   CatalogueCsvFormat{System: "snomed-ct", Comma: '\t'},
+			expected: []CatalogueCondition{{Id: "snomed-ct:82272006", System: "snomed-ct", Code: "82272006", Value: "Common cold"}},
+		},
+		{
+			name:     "ignores byte order mark and case of the header",
+			input:    "\ufeffCODE, Display \nJ00,Acute nasopharyngitis\n",
+			format:   icd10,
+			expected: []CatalogueCondition{{Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis"}},
+		},
+		{
+			name:     "uses configured columns",
+			input:    "code,name,kod\nA,Ignored,J00\n",
+			format:   CatalogueCsvFormat{System: "icd-10", CodeColumn: "kod"},
+			expected: []CatalogueCondition{{Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Ignored"}},
+		},
+		{
+			name:     "skips inactive rows",
+			input:    "active,code,value\n0,J00,Retired\n1,J01,Acute sinusitis\n",
+			format:   icd10,
+			expected: []CatalogueCondition{{Id: "icd-10:J01", System: "icd-10", Code: "J01", Value: "Acute sinusitis"}},
+		},
+		{
+			name:     "uses first row of duplicate codes",
+			input:    "code,value\nJ00,Acute nasopharyngitis\nJ00,Common cold\n,Without code\n",
+			format:   icd10,
+			expected: []CatalogueCondition{{Id: "icd-10:J00", System: "icd-10", Code: "J00", Value: "Acute nasopharyngitis"}},
+		},
+		{
+			name:   "rejects duration which is not a number",
+			input:  "code,value,duration\nJ00,Acute nasopharyngitis,quarter\n",
+			format: icd10,
+			err:    "line 2: typical duration quarter",
+		},
+		{
+			name:   "rejects too long duration",
+			input:  "code,value,duration\nJ00,Acute nasopharyngitis,1441\n",
+			format: icd10,
+			err:    "line 2: typical duration must be between",
+		},
+		{
+			name:   "rejects missing value column",
+			input:  "code,reference\nJ00,https://icd.who.int/J00\n",
+			format: icd10,
+			err:    "code list must have the code and value columns",
+		},
+		{
+			name:   "rejects unknown code system",
+			input:  "code,value\nJ00,Acute nasopharyngitis\n",
+			format: CatalogueCsvFormat{System: "icd-11"},
+			err:    "code system must be one of",
+		},
+	}
+
+	for _, test := range tests {
+		suite.Run(test.name, func() {
+			// ACT
+			conditions, err := ReadConditionCatalogue(strings.NewReader(test.input), test.format)
+
+			// ASSERT
+			if test.err != "" {
+				suite.Require().Error(err)
+				suite.Contains(err.Error(), test.err)
+				return
+			}
+			suite.Require().NoError(err)
+			suite.Equal(test.expected, conditions)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package ambulance_wl
+
+import (
+	"context"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+const (
+	// completed visits older than the window do not reflect the current practice of the ambulance
+	learnedDurationWindow = 90 * 24 * time.Hour
+	// minimal number of completed visits of the condition for the learned duration to be trusted
+	minLearnedVisits = 5
+	// the durations are learned from at most this number of the most recent visits
+	maxLearnedVisits = 2000
+	// the learned durations change slowly, the entries created meanwhile reuse them
+	learnedDurationsTtl = 10 * time.Minute
+)
+
+type learnedDurationsKey struct {
+	visits      db_service.DbService[Visit]
+	ambulanceId string
+}
+
+type cachedDurations struct {
+	learnedAt     time.Time
+	distributions map[string]*durationDistribution
+}
+
+// learnedDurationsCache keeps the learned durations of the ambulances for learnedDurationsTtl
+var learnedDurationsCache = struct {
+	sync.Mutex
+	entries map[learnedDurationsKey]cachedDurations
+}{entries: map[learnedDurationsKey]cachedDurations{}}
+
+// durationDistribution collects the actual treatment durations of the completed visits of one condition
+type durationDistribution struct {
+	condition Condition
+	// sorted ascending
+	minutes []int32
+}
+
+// percentile of the durations by the nearest-rank method, p is from (0, 1]
+func (this *durationDistribution) percentile(p float64) int32 {
+	if len(this.minutes) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(this.minutes)))) - 1
+	return this.minutes[max(rank, 0)]
+}
+
+// learned returns the median duration if there are enough visits to trust it
+func (this *durationDistribution) learned() (int32, bool) {
+	if this == nil || len(this.minutes) < minLearnedVisits {
+		return 0, false
+	}
+	return this.percentile(0.5), true
+}
+
+// learnDurations groups the treatment durations of the recently completed visits of the ambulance by the condition code
+func learnDurations(ctx context.Context, visits db_service.DbService[Visit], ambulanceId string, now time.Time) (map[string]*durationDistribution, error) {
+	completed, _, err := visits.ListDocuments(ctx, db_service.ListQuery{
+		Equals: map[string]string{
+			"AmbulanceId": ambulanceId,
+			"Status":      statusCompleted,
+		},
+		Between: map[string]db_service.TimeRange{
+			"WaitingSince": {From: now.Add(-learnedDurationWindow)},
+		},
+		SortBy:     "WaitingSince",
+		Descending: true,
+		Limit:      maxLearnedVisits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	distributions := map[string]*durationDistribution{}
+	for _, visit := range completed {
+		if visit.Condition.Code == "" || visit.TreatmentMinutes <= 0 {
+			continue
+		}
+		distribution, ok := distributions[visit.Condition.Code]
+		if !ok {
+			distribution = &durationDistribution{condition: visit.Condition}
+			distributions[visit.Condition.Code] = distribution
+		}
+		distribution.minutes = append(distribution.minutes, visit.TreatmentMinutes)
+	}
+	for _, distribution := range distributions {
+		slices.Sort(distribution.minutes)
+	}
+	return distributions, nil
+}
+
+// learnedDurations is the best effort variant of learnDurations for the estimates of new entries,
+// if the visits archive is not available the configured durations are used. The durations are
+// learned at most once per learnedDurationsTtl for each ambulance, also when the update is retried.
+func learnedDurations(ctx *gin.Context, ambulanceId string) map[string]*durationDistribution {
+	visits, err := visitsArchive(ctx)
+	if err != nil {
+		log.Printf("Learned durations not available: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	key := learnedDurationsKey{visits: visits, ambulanceId: ambulanceId}
+	learnedDurationsCache.Lock()
+	cached, ok := learnedDurationsCache.entries[key]
+	learnedDurationsCache.Unlock()
+	if ok && now.Sub(cached.learnedAt) < learnedDurationsTtl {
+		return cached.distributions
+	}
+
+	distributions, err := learnDurations(ctx.Request.Context(), visits, ambulanceId, now)
+	if err != nil {
+		log.Printf("Failed to learn durations of the ambulance %v: %v", ambulanceId, err)
+		return nil
+	}
+	learnedDurationsCache.Lock()
+	// the ambulances which are not used anymore do not stay in the cache
+	for cachedKey, cached := range learnedDurationsCache.entries {
+		if now.Sub(cached.learnedAt) >= learnedDurationsTtl {
+			delete(learnedDurationsCache.entries, cachedKey)
+		}
+	}
+	learnedDurationsCache.entries[key] = cachedDurations{learnedAt: now, distributions: distributions}
+	learnedDurationsCache.Unlock()
+	return distributions
+}
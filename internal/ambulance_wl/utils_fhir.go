@@ -0,0 +1,363 @@
+package ambulance_wl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FHIR R4 representation of the ambulance data, only the elements the ambulance can fill are modelled.
+// See https://hl7.org/fhir/R4/resourcelist.html
+
+const (
+	fhirContentType = "application/fhir+json; fhirVersion=4.0"
+
+	fhirActCodeSystem      = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	fhirActPrioritySystem  = "http://terminology.hl7.org/CodeSystem/v3-ActPriority"
+	fhirPhysicalTypeSystem = "http://terminology.hl7.org/CodeSystem/location-physical-type"
+)
+
+// FHIR systems of the catalogue code systems, local codes have no well known system
+var fhirCodeSystems = map[string]string{
+	"icd-10":    "http://hl7.org/fhir/sid/icd-10",
+	"snomed-ct": "http://snomed.info/sct",
+}
+
+type fhirMeta struct {
+	VersionId string `json:"versionId,omitempty"`
+}
+
+type fhirCoding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type fhirCodeableConcept struct {
+	Coding []fhirCoding `json:"coding,omitempty"`
+	Text   string       `json:"text,omitempty"`
+}
+
+type fhirReference struct {
+	Reference string `json:"reference"`
+	Display   string `json:"display,omitempty"`
+}
+
+type fhirIdentifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type fhirPeriod struct {
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+type fhirHealthcareService struct {
+	ResourceType           string              `json:"resourceType"`
+	Id                     string              `json:"id"`
+	Meta                   *fhirMeta           `json:"meta,omitempty"`
+	Active                 bool                `json:"active"`
+	Name                   string              `json:"name,omitempty"`
+	Comment                string              `json:"comment,omitempty"`
+	Location               []fhirReference     `json:"location,omitempty"`
+	AvailableTime          []fhirAvailableTime `json:"availableTime,omitempty"`
+	AvailabilityExceptions string              `json:"availabilityExceptions,omitempty"`
+}
+
+type fhirAvailableTime struct {
+	DaysOfWeek         []string `json:"daysOfWeek,omitempty"`
+	AllDay             bool     `json:"allDay,omitempty"`
+	AvailableStartTime string   `json:"availableStartTime,omitempty"`
+	AvailableEndTime   string   `json:"availableEndTime,omitempty"`
+}
+
+type fhirLocation struct {
+	ResourceType string               `json:"resourceType"`
+	Id           string               `json:"id"`
+	Status       string               `json:"status"`
+	Name         string               `json:"name,omitempty"`
+	Description  string               `json:"description,omitempty"`
+	Mode         string               `json:"mode"`
+	PhysicalType *fhirCodeableConcept `json:"physicalType,omitempty"`
+}
+
+type fhirEncounter struct {
+	ResourceType string                  `json:"resourceType"`
+	Id           string                  `json:"id"`
+	Identifier   []fhirIdentifier        `json:"identifier,omitempty"`
+	Status       string                  `json:"status"`
+	Class        fhirCoding              `json:"class"`
+	Priority     *fhirCodeableConcept    `json:"priority,omitempty"`
+	Subject      *fhirReference          `json:"subject,omitempty"`
+	Period       *fhirPeriod             `json:"period,omitempty"`
+	ReasonCode   []fhirCodeableConcept   `json:"reasonCode,omitempty"`
+	Location     []fhirEncounterLocation `json:"location,omitempty"`
+}
+
+type fhirEncounterLocation struct {
+	Location fhirReference `json:"location"`
+}
+
+type fhirAppointment struct {
+	ResourceType    string                       `json:"resourceType"`
+	Id              string                       `json:"id"`
+	Status          string                       `json:"status"`
+	Start           *time.Time                   `json:"start,omitempty"`
+	End             *time.Time                   `json:"end,omitempty"`
+	MinutesDuration int                          `json:"minutesDuration,omitempty"`
+	Comment         string                       `json:"comment,omitempty"`
+	Participant     []fhirAppointmentParticipant `json:"participant"`
+}
+
+type fhirAppointmentParticipant struct {
+	Actor    fhirReference `json:"actor"`
+	Required string        `json:"required,omitempty"`
+	Status   string        `json:"status"`
+}
+
+type fhirBundle struct {
+	ResourceType string            `json:"resourceType"`
+	Type         string            `json:"type"`
+	Total        int               `json:"total"`
+	Link         []fhirBundleLink  `json:"link,omitempty"`
+	Entry        []fhirBundleEntry `json:"entry,omitempty"`
+}
+
+type fhirBundleLink struct {
+	Relation string `json:"relation"`
+	Url      string `json:"url"`
+}
+
+type fhirBundleEntry struct {
+	FullUrl  string           `json:"fullUrl"`
+	Resource interface{}      `json:"resource"`
+	Search   fhirBundleSearch `json:"search"`
+}
+
+type fhirBundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type fhirOperationOutcome struct {
+	ResourceType string      `json:"resourceType"`
+	Issue        []fhirIssue `json:"issue"`
+}
+
+type fhirIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// searchsetBundle collects the resources found on the base url of the ambulance
+func searchsetBundle(self string) *fhirBundle {
+	return &fhirBundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Link:         []fhirBundleLink{{Relation: "self", Url: self}},
+	}
+}
+
+func (this *fhirBundle) add(base string, resourceType string, id string, resource interface{}) {
+	this.Entry = append(this.Entry, fhirBundleEntry{
+		FullUrl:  fmt.Sprintf("%v/%v/%v", base, resourceType, id),
+		Resource: resource,
+		Search:   fhirBundleSearch{Mode: "match"},
+	})
+	this.Total = len(this.Entry)
+}
+
+// fhirInstant omits the times not set
+func fhirInstant(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	value = value.UTC()
+	return &value
+}
+
+// fhirHealthcareService describes the ambulance, its rooms and the opening hours
+func (this *Ambulance) fhirHealthcareService() fhirHealthcareService {
+	service := fhirHealthcareService{
+		ResourceType: "HealthcareService",
+		Id:           this.Id,
+		Active:       true,
+		Name:         this.Name,
+	}
+	if this.Version > 0 {
+		service.Meta = &fhirMeta{VersionId: fmt.Sprint(this.Version)}
+	}
+	if this.RoomNumber != "" {
+		service.Comment = "Room " + this.RoomNumber
+	}
+	for _, room := range this.Rooms {
+		service.Location = append(service.Location, fhirReference{
+			Reference: "Location/" + room.Id,
+			Display:   room.Name,
+		})
+	}
+
+	// ambulance without opening hours is always open
+	if len(this.Settings.OpeningHours) == 0 {
+		service.AvailableTime = []fhirAvailableTime{{AllDay: true}}
+	}
+	for _, interval := range this.Settings.OpeningHours {
+		service.AvailableTime = append(service.AvailableTime, fhirAvailableTime{
+			DaysOfWeek:         fhirDaysOfWeek(interval.Day),
+			AvailableStartTime: interval.From + ":00",
+			AvailableEndTime:   interval.Until + ":00",
+		})
+	}
+	breaks := []string{}
+	for _, interval := range this.Settings.Breaks {
+		description := interval.From + "-" + interval.Until
+		if interval.Day != "" {
+			description += " on " + strings.ToLower(interval.Day)
+		}
+		breaks = append(breaks, description)
+	}
+	if len(breaks) > 0 {
+		service.AvailabilityExceptions = "Breaks " + strings.Join(breaks, ", ")
+	}
+	if timeZone := this.Settings.TimeZone; timeZone != "" {
+		service.AvailabilityExceptions = strings.TrimSpace(service.AvailabilityExceptions + " (times in " + timeZone + ")")
+	}
+	return service
+}
+
+// fhirDaysOfWeek is the day in the FHIR days-of-week code system, every day if not set
+func fhirDaysOfWeek(day string) []string {
+	if day == "" {
+		return nil
+	}
+	return []string{strings.ToLower(day)[:3]}
+}
+
+func (this *Room) fhirLocation() fhirLocation {
+	location := fhirLocation{
+		ResourceType: "Location",
+		Id:           this.Id,
+		Status:       "active",
+		Name:         this.Name,
+		Description:  this.Equipment,
+		Mode:         "instance",
+		PhysicalType: &fhirCodeableConcept{
+			Coding: []fhirCoding{{System: fhirPhysicalTypeSystem, Code: "ro", Display: "Room"}},
+		},
+	}
+	if location.Name == "" {
+		location.Name = this.Id
+	}
+	return location
+}
+
+// fhirEncounterStatus maps the state of the visit, patients registered ahead of their arrival are planned
+func (this *WaitingListEntry) fhirEncounterStatus(now time.Time) string {
+	switch this.status() {
+	case statusCalled:
+		return "arrived"
+	case statusInTreatment:
+		return "in-progress"
+	case statusCompleted:
+		return "finished"
+	case statusNoShow:
+		return "cancelled"
+	}
+	if this.WaitingSince.After(now) {
+		return "planned"
+	}
+	return "arrived"
+}
+
+// fhirPriority maps the Emergency Severity Index to the act priority
+func (this *WaitingListEntry) fhirPriority() *fhirCodeableConcept {
+	coding := fhirCoding{System: fhirActPrioritySystem, Code: "R", Display: "routine"}
+	switch this.Priority {
+	case 1:
+		coding = fhirCoding{System: fhirActPrioritySystem, Code: "EM", Display: "emergency"}
+	case 2:
+		coding = fhirCoding{System: fhirActPrioritySystem, Code: "UR", Display: "urgent"}
+	}
+	return &fhirCodeableConcept{Coding: []fhirCoding{coding}}
+}
+
+// fhirEncounter describes the visit of the waiting list entry, codeSystems provide
+// the FHIR systems of the condition codes, codes without the system are local
+func (this *WaitingListEntry) fhirEncounter(ambulance *Ambulance, now time.Time, codeSystems map[string]string) fhirEncounter {
+	encounter := fhirEncounter{
+		ResourceType: "Encounter",
+		Id:           this.Id,
+		Status:       this.fhirEncounterStatus(now),
+		Class:        fhirCoding{System: fhirActCodeSystem, Code: "AMB", Display: "ambulatory"},
+		Priority:     this.fhirPriority(),
+	}
+	if this.TicketNumber != "" {
+		encounter.Identifier = []fhirIdentifier{{Use: "secondary", Value: this.TicketNumber}}
+	}
+	if this.PatientId != "" {
+		encounter.Subject = &fhirReference{Reference: "Patient/" + this.PatientId, Display: this.Name}
+	}
+	if encounter.Status != "planned" {
+		end := this.ActualEnd
+		if end.IsZero() {
+			end = this.NoShowAt
+		}
+		encounter.Period = &fhirPeriod{Start: fhirInstant(this.WaitingSince), End: fhirInstant(end)}
+	}
+	if this.Condition.Code != "" || this.Condition.Value != "" {
+		reason := fhirCodeableConcept{Text: this.Condition.Value}
+		if this.Condition.Code != "" {
+			reason.Coding = []fhirCoding{{
+				System:  codeSystems[this.Condition.Code],
+				Code:    this.Condition.Code,
+				Display: this.Condition.Value,
+			}}
+		}
+		encounter.ReasonCode = []fhirCodeableConcept{reason}
+	}
+	if this.RoomId != "" {
+		location := fhirReference{Reference: "Location/" + this.RoomId}
+		if roomIndx := slices.IndexFunc(ambulance.Rooms, func(room Room) bool {
+			return room.Id == this.RoomId
+		}); roomIndx >= 0 {
+			location.Display = ambulance.Rooms[roomIndx].Name
+		}
+		encounter.Location = []fhirEncounterLocation{{Location: location}}
+	}
+	return encounter
+}
+
+// fhirAppointment describes the scheduled visit of the patient in the room of the ambulance
+func (this *Schedule) fhirAppointment(ambulance *Ambulance) fhirAppointment {
+	appointment := fhirAppointment{
+		ResourceType: "Appointment",
+		Id:           this.Id,
+		Status:       "booked",
+		Start:        fhirInstant(this.Start),
+		End:          fhirInstant(this.End),
+		Comment:      this.Note,
+	}
+	if this.End.After(this.Start) && !this.Start.IsZero() {
+		appointment.MinutesDuration = int(this.End.Sub(this.Start).Minutes())
+	}
+	participant := func(reference string) fhirAppointmentParticipant {
+		return fhirAppointmentParticipant{
+			Actor:    fhirReference{Reference: reference},
+			Required: "required",
+			Status:   "accepted",
+		}
+	}
+	if this.PatientId != "" {
+		appointment.Participant = append(appointment.Participant, participant("Patient/"+this.PatientId))
+	}
+	if this.RoomId != "" {
+		appointment.Participant = append(appointment.Participant, participant("Location/"+this.RoomId))
+	}
+	service := participant("HealthcareService/" + ambulance.Id)
+	service.Actor.Display = ambulance.Name
+	appointment.Participant = append(appointment.Participant, service)
+	return appointment
+}
@@ -0,0 +1,51 @@
+package ambulance_wl
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xlukacs/ambulance-webapi/internal/broadcast"
+)
+
+// number of recent waiting lists of each ambulance kept for the reconnecting subscribers
+const waitingListEventsHistory = 64
+
+// interval of the comments sent over the idle event stream
+var waitingListKeepAliveInterval = 15 * time.Second
+
+// names of the server-sent events
+const (
+	waitingListEventName     = "waiting-list"
+	estimateShiftedEventName = "estimate-shifted"
+)
+
+// WaitingListEvent is sent to the subscribers of the ambulance, Data is the waiting list
+// or the EstimateShift, depending on the Name
+type WaitingListEvent struct {
+	Name string
+	Data interface{}
+}
+
+// WaitingListEvents delivers the changes of the waiting lists to the subscribers, topics are the ambulance ids
+type WaitingListEvents = broadcast.Broadcaster[WaitingListEvent]
+
+func NewWaitingListEvents() *WaitingListEvents {
+	return broadcast.New[WaitingListEvent](waitingListEventsHistory)
+}
+
+// publishWaitingList notifies the subscribers about the persisted waiting list of the ambulance
+func publishWaitingList(ctx *gin.Context, ambulance *Ambulance) {
+	value, exists := ctx.Get("waiting_list_events")
+	if !exists {
+		return
+	}
+	if events, ok := value.(*WaitingListEvents); ok {
+		publishWaitingListTo(events, ambulance)
+	}
+}
+
+func publishWaitingListTo(events *WaitingListEvents, ambulance *Ambulance) {
+	// the subscribers must not share the list with the ambulance
+	waitingList := append([]WaitingListEntry{}, ambulance.WaitingList...)
+	events.Publish(ambulance.Id, WaitingListEvent{Name: waitingListEventName, Data: waitingList})
+}
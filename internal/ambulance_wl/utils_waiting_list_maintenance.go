@@ -0,0 +1,187 @@
+package ambulance_wl
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// number of ambulances loaded from the database at once by the maintenance
+	maintenancePageSize = 50
+	// smaller changes of the estimates are not worth storing
+	estimateTolerance = time.Minute
+)
+
+// WaitingListMaintenance periodically takes care of the waiting lists nobody is changing - removes
+// the entries left in the list after the patient has gone and re-estimates the waiting times as
+// the time passes. Several instances of the service may run it concurrently, the changes are
+// protected by the ambulance version.
+type WaitingListMaintenance struct {
+	ambulances db_service.DbService[Ambulance]
+	visits     db_service.DbService[Visit]
+	events     *WaitingListEvents
+}
+
+func NewWaitingListMaintenance(
+	ambulances db_service.DbService[Ambulance],
+	visits db_service.DbService[Visit],
+	events *WaitingListEvents,
+) *WaitingListMaintenance {
+	return &WaitingListMaintenance{
+		ambulances: ambulances,
+		visits:     visits,
+		events:     events,
+	}
+}
+
+// Run maintains all ambulances in the given interval until the context is cancelled
+func (this *WaitingListMaintenance) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			this.maintainAll(ctx, time.Now())
+		}
+	}
+}
+
+func (this *WaitingListMaintenance) maintainAll(ctx context.Context, now time.Time) {
+	ctx, span := tracer.Start(ctx, "maintainWaitingLists")
+	defer span.End()
+
+	for offset := int64(0); ; offset += maintenancePageSize {
+		ambulances, total, err := this.ambulances.ListDocuments(ctx, db_service.ListQuery{
+			SortBy: "Id",
+			Offset: offset,
+			Limit:  maintenancePageSize,
+		})
+		if err != nil {
+			log.Printf("Failed to list ambulances for the maintenance: %v", err)
+			return
+		}
+		for _, ambulance := range ambulances {
+			if err := this.maintain(ctx, ambulance.Id, now); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to maintain the waiting list of the ambulance %v: %v", ambulance.Id, err)
+			}
+		}
+		if len(ambulances) == 0 || offset+maintenancePageSize >= total {
+			return
+		}
+	}
+}
+
+// maintain expires the stale entries of the ambulance, archives their visits, assigns the tickets
+// to the entries created without them and re-estimates the waiting entries, the change is
+// re-applied if the ambulance was modified concurrently
+func (this *WaitingListMaintenance) maintain(ctx context.Context, ambulanceId string, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "maintainWaitingList",
+		trace.WithAttributes(attribute.String("ambulance_id", ambulanceId)),
+	)
+	defer span.End()
+
+	// the retries archive the visits of the same entries with the same ids
+	visitIds := map[string]string{}
+	var err error
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		var ambulance *Ambulance
+		if ambulance, err = this.ambulances.FindDocument(ctx, ambulanceId); err != nil {
+			return err
+		}
+
+		expired := ambulance.expireEntries(now)
+		ticketed := ambulance.assignMissingTickets(now)
+		previous := estimatedStarts(ambulance)
+		ambulance.reconcileWaitingList(ctx)
+		shifts, changed := estimateShifts(ambulance, previous)
+		if len(expired) == 0 && !ticketed && !changed {
+			return nil
+		}
+
+		patch := waitingListPatch(ambulance)
+		if ticketed {
+			patch = ticketedWaitingListPatch(ambulance)
+		}
+		for _, visit := range expired {
+			if id, ok := visitIds[visit.EntryId]; ok {
+				visit.Id = id
+			}
+			visitIds[visit.EntryId] = visit.Id
+			patch = archiveVisitPatch(this.visits, visit, patch)
+		}
+		switch err = patch(ctx, this.ambulances); err {
+		case nil:
+			if len(expired) > 0 {
+				log.Printf("Expired %v entries of the waiting list of the ambulance %v", len(expired), ambulanceId)
+			}
+			publishWaitingListTo(this.events, ambulance)
+			for _, shift := range shifts {
+				this.events.Publish(ambulanceId, WaitingListEvent{Name: estimateShiftedEventName, Data: shift})
+			}
+			return nil
+		case db_service.ErrVersionMismatch:
+			// reload and try again
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// estimatedStarts of the waiting entries by their ids
+func estimatedStarts(ambulance *Ambulance) map[string]time.Time {
+	starts := map[string]time.Time{}
+	for i := range ambulance.WaitingList {
+		if entry := &ambulance.WaitingList[i]; entry.isWaiting() {
+			starts[entry.Id] = entry.EstimatedStart
+		}
+	}
+	return starts
+}
+
+// estimateShifts compares the estimates of the reconciled waiting entries with the last announced ones,
+// so that the slow drift is announced once it adds up. The shifts exceeding the threshold of the ambulance
+// are returned and recorded as announced, changed is true if any estimate is worth storing.
+func estimateShifts(ambulance *Ambulance, previous map[string]time.Time) (shifts []EstimateShift, changed bool) {
+	threshold := ambulance.Settings.estimateShiftThreshold()
+	for i := range ambulance.WaitingList {
+		entry := &ambulance.WaitingList[i]
+		if !entry.isWaiting() {
+			continue
+		}
+		before := previous[entry.Id]
+		if before.IsZero() || entry.EstimatedStart.Sub(before).Abs() >= estimateTolerance {
+			changed = true
+		}
+		// the entries created since the last maintenance were announced by their creation
+		announced := entry.AnnouncedEstimatedStart
+		if announced.IsZero() {
+			announced = before
+		}
+		if announced.IsZero() {
+			entry.AnnouncedEstimatedStart = entry.EstimatedStart
+			changed = true
+			continue
+		}
+		entry.AnnouncedEstimatedStart = announced
+		if entry.EstimatedStart.Sub(announced).Abs() > threshold {
+			shifts = append(shifts, EstimateShift{
+				EntryId:                entry.Id,
+				TicketNumber:           entry.TicketNumber,
+				PreviousEstimatedStart: announced,
+				EstimatedStart:         entry.EstimatedStart,
+				ShiftMinutes:           minutesBetween(announced, entry.EstimatedStart),
+			})
+			entry.AnnouncedEstimatedStart = entry.EstimatedStart
+			changed = true
+		}
+	}
+	return shifts, changed
+}
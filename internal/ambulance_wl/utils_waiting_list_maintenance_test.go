@@ -0,0 +1,187 @@
+package ambulance_wl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/xlukacs/ambulance-webapi/internal/db_service"
+)
+
+type WaitingListMaintenanceSuite struct {
+	suite.Suite
+}
+
+func TestWaitingListMaintenanceSuite(t *testing.T) {
+	suite.Run(t, new(WaitingListMaintenanceSuite))
+}
+
+// archivedStatuses maps the ids of the archived entries to the statuses of their visits
+func (suite *WaitingListMaintenanceSuite) archivedStatuses(visits db_service.DbService[Visit]) map[string]string {
+	archived, _, err := visits.ListDocuments(context.Background(), db_service.ListQuery{})
+	suite.Require().NoError(err)
+	statuses := map[string]string{}
+	for _, visit := range archived {
+		statuses[visit.EntryId] = visit.Status
+	}
+	return statuses
+}
+
+func (suite *WaitingListMaintenanceSuite) Test_Maintenance_StaleEntriesExpired() {
+	// ARRANGE
+	now := time.Date(2038, 12, 24, 17, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time { return time.Date(2038, 12, 24, hour, minute, 0, 0, time.UTC) }
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"stale": {
+			Id:       "stale",
+			Settings: AmbulanceSettings{TimeZone: "UTC", OpeningHours: []TimeInterval{{From: "08:00", Until: "16:00"}}},
+			WaitingList: []WaitingListEntry{
+				{Id: "forgotten", PatientId: "p1", WaitingSince: at(9, 0)},
+				{Id: "not-showing", PatientId: "p2", WaitingSince: at(9, 0), Status: statusCalled, CalledAt: at(16, 0)},
+				{Id: "on-the-way", PatientId: "p3", WaitingSince: at(9, 0), Status: statusCalled, CalledAt: at(16, 50)},
+				{Id: "tomorrow", PatientId: "p4", WaitingSince: at(16, 45)},
+			},
+		},
+	})
+	visits := newMemoryStore(suite.T(), map[string]Visit{})
+	sut := NewWaitingListMaintenance(db, visits, NewWaitingListEvents())
+
+	// ACT
+	sut.maintainAll(context.Background(), now)
+
+	// ASSERT
+	stored, _ := db.FindDocument(context.Background(), "stale")
+	remaining := []string{}
+	for _, entry := range stored.WaitingList {
+		remaining = append(remaining, entry.Id)
+	}
+	suite.ElementsMatch([]string{"on-the-way", "tomorrow"}, remaining)
+	suite.Equal(map[string]string{"forgotten": statusExpired, "not-showing": statusNoShow}, suite.archivedStatuses(visits))
+}
+
+func (suite *WaitingListMaintenanceSuite) Test_Maintenance_StaleEntriesOfAlwaysOpenAmbulanceExpired() {
+	// ARRANGE
+	now := time.Date(2038, 12, 24, 17, 0, 0, 0, time.UTC)
+	ambulance := Ambulance{
+		Id:       "always-open",
+		Settings: AmbulanceSettings{StaleEntryMinutes: 120},
+		WaitingList: []WaitingListEntry{
+			{Id: "forgotten", PatientId: "p1", WaitingSince: now.Add(-3 * time.Hour)},
+			{Id: "waiting", PatientId: "p2", WaitingSince: now.Add(-time.Hour)},
+			{Id: "left-in-treatment", PatientId: "p3", WaitingSince: now.Add(-4 * time.Hour),
+				Status: statusInTreatment, ActualStart: now.Add(-150 * time.Minute)},
+			{Id: "long-treatment", PatientId: "p4", WaitingSince: now.Add(-4 * time.Hour),
+				Status: statusInTreatment, ActualStart: now.Add(-90 * time.Minute)},
+		},
+	}
+
+	// ACT
+	expired := ambulance.expireEntries(now)
+
+	// ASSERT
+	expiredIds := []string{}
+	for _, visit := range expired {
+		suite.Equal(statusExpired, visit.Status)
+		expiredIds = append(expiredIds, visit.EntryId)
+	}
+	suite.ElementsMatch([]string{"forgotten", "left-in-treatment"}, expiredIds)
+	remaining := []string{}
+	for _, entry := range ambulance.WaitingList {
+		remaining = append(remaining, entry.Id)
+	}
+	suite.ElementsMatch([]string{"waiting", "long-treatment"}, remaining)
+}
+
+func (suite *WaitingListMaintenanceSuite) Test_Maintenance_LegacyEntriesGetTickets() {
+	// ARRANGE
+	now := time.Now()
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"legacy": {
+			Id:       "legacy",
+			Settings: AmbulanceSettings{TicketPrefix: "B"},
+			WaitingList: []WaitingListEntry{
+				{Id: "numbered", PatientId: "p1", WaitingSince: now, TicketNumber: "B001"},
+				{Id: "legacy-entry", PatientId: "p2", WaitingSince: now},
+			},
+			TicketCounter: TicketCounter{Day: now.UTC().Format(time.DateOnly), Last: 1},
+		},
+	})
+	sut := NewWaitingListMaintenance(db, newMemoryStore(suite.T(), map[string]Visit{}), NewWaitingListEvents())
+
+	// ACT
+	sut.maintainAll(context.Background(), now)
+
+	// ASSERT
+	stored, _ := db.FindDocument(context.Background(), "legacy")
+	tickets := map[string]string{}
+	for _, entry := range stored.WaitingList {
+		tickets[entry.Id] = entry.TicketNumber
+	}
+	suite.Equal(map[string]string{"numbered": "B001", "legacy-entry": "B002"}, tickets)
+	suite.Equal(int32(2), stored.TicketCounter.Last)
+}
+
+func (suite *WaitingListMaintenanceSuite) Test_Maintenance_OverrunPushesEstimatesBack() {
+	// ARRANGE
+	now := time.Now()
+	db := newMemoryStore(suite.T(), map[string]Ambulance{
+		"overrun": {
+			Id: "overrun",
+			WaitingList: []WaitingListEntry{
+				{Id: "treated", PatientId: "p1", WaitingSince: now.Add(-time.Hour), Status: statusInTreatment,
+					ActualStart: now.Add(-40 * time.Minute), EstimatedDurationMinutes: 15},
+				{Id: "waiting", PatientId: "p2", WaitingSince: now.Add(-time.Hour),
+					EstimatedStart: now.Add(-25 * time.Minute), EstimatedDurationMinutes: 15},
+			},
+		},
+	})
+	events := NewWaitingListEvents()
+	_, _, stream, cancel := events.Subscribe("overrun", "")
+	defer cancel()
+	sut := NewWaitingListMaintenance(db, newMemoryStore(suite.T(), map[string]Visit{}), events)
+
+	// ACT
+	sut.maintainAll(context.Background(), now)
+
+	// ASSERT
+	stored, _ := db.FindDocument(context.Background(), "overrun")
+	suite.False(stored.WaitingList[1].EstimatedStart.Before(now))
+	suite.Equal(waitingListEventName, (<-stream).Data.Name)
+	shifted := <-stream
+	suite.Require().Equal(estimateShiftedEventName, shifted.Data.Name)
+	suite.Equal("waiting", shifted.Data.Data.(EstimateShift).EntryId)
+	suite.GreaterOrEqual(shifted.Data.Data.(EstimateShift).ShiftMinutes, int32(25))
+}
+
+func (suite *WaitingListMaintenanceSuite) Test_Maintenance_SlowDriftAnnouncedOnceAccumulated() {
+	// ARRANGE
+	start := time.Now().Add(time.Hour)
+	ambulance := Ambulance{
+		Id: "drift",
+		WaitingList: []WaitingListEntry{
+			{Id: "waiting", PatientId: "p1", WaitingSince: start.Add(-time.Hour), EstimatedStart: start},
+		},
+	}
+
+	// ACT
+	var announced [][]EstimateShift
+	for tick := 1; tick <= 5; tick++ {
+		previous := estimatedStarts(&ambulance)
+		// each re-estimation moves the visit by less than the threshold
+		ambulance.WaitingList[0].EstimatedStart = start.Add(time.Duration(tick) * 90 * time.Second)
+		shifts, changed := estimateShifts(&ambulance, previous)
+		suite.True(changed)
+		announced = append(announced, shifts)
+	}
+
+	// ASSERT
+	suite.Empty(announced[0])
+	suite.Empty(announced[1])
+	suite.Empty(announced[2])
+	suite.Require().Len(announced[3], 1)
+	suite.Equal(start, announced[3][0].PreviousEstimatedStart)
+	suite.Equal(int32(6), announced[3][0].ShiftMinutes)
+	suite.Empty(announced[4])
+	suite.Equal(start.Add(6*time.Minute), ambulance.WaitingList[0].AnnouncedEstimatedStart)
+}
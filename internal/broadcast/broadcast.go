@@ -0,0 +1,150 @@
+package broadcast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event published to the subscribers of the topic. Id is the resume token of the event.
+type Event[Data interface{}] struct {
+	Id   string
+	Data Data
+}
+
+// Broadcaster delivers the events published in this process to the subscribers of their topic.
+// The recent events of each topic are kept so that reconnecting subscribers receive the events
+// they missed. Event ids are valid only in the process which published them.
+type Broadcaster[Data interface{}] struct {
+	// identifies the process in the event ids, ids of other processes are not resumable
+	epoch       string
+	historySize int
+	// buffered events of each subscriber, slow subscribers are disconnected
+	BufferSize int
+
+	lock     sync.Mutex
+	closed   bool
+	sequence uint64
+	history  map[string][]sequencedEvent[Data]
+	// sequence of the latest event of each topic which is not kept anymore
+	dropped  map[string]uint64
+	channels map[string]map[chan Event[Data]]struct{}
+}
+
+type sequencedEvent[Data interface{}] struct {
+	sequence uint64
+	event    Event[Data]
+}
+
+// New creates the broadcaster keeping historySize recent events of each topic
+func New[Data interface{}](historySize int) *Broadcaster[Data] {
+	return &Broadcaster[Data]{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize: historySize,
+		BufferSize:  16,
+		history:     map[string][]sequencedEvent[Data]{},
+		dropped:     map[string]uint64{},
+		channels:    map[string]map[chan Event[Data]]struct{}{},
+	}
+}
+
+// Publish delivers the data to the current subscribers of the topic and keeps it for the reconnecting ones
+func (this *Broadcaster[Data]) Publish(topic string, data Data) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.closed {
+		return
+	}
+
+	this.sequence++
+	event := Event[Data]{Id: fmt.Sprintf("%v-%v", this.epoch, this.sequence), Data: data}
+	history := append(this.history[topic], sequencedEvent[Data]{this.sequence, event})
+	if len(history) > this.historySize {
+		this.dropped[topic] = history[len(history)-this.historySize-1].sequence
+		history = history[len(history)-this.historySize:]
+	}
+	this.history[topic] = history
+
+	for channel := range this.channels[topic] {
+		select {
+		case channel <- event:
+		default:
+			// the subscriber does not keep up, it has to reconnect and resume
+			this.unsubscribeLocked(topic, channel)
+		}
+	}
+}
+
+// Subscribe starts receiving the events of the topic. If all events of the topic published after
+// lastEventId are still kept, they are returned as missed and resumed is true. Otherwise the subscriber
+// has to obtain the current state by other means. The channel is closed when
+// the subscriber is disconnected; cancel must be called when the subscriber is not interested anymore.
+func (this *Broadcaster[Data]) Subscribe(topic string, lastEventId string) (missed []Event[Data], resumed bool, events <-chan Event[Data], cancel func()) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	channel := make(chan Event[Data], this.BufferSize)
+	if this.closed {
+		close(channel)
+		return nil, false, channel, func() {}
+	}
+
+	// resumable only if no event published after the last one was dropped from the history
+	if sequence, ok := this.parseId(lastEventId); ok && sequence >= this.dropped[topic] && sequence <= this.sequence {
+		resumed = true
+		for _, current := range this.history[topic] {
+			if current.sequence > sequence {
+				missed = append(missed, current.event)
+			}
+		}
+	}
+
+	if this.channels[topic] == nil {
+		this.channels[topic] = map[chan Event[Data]]struct{}{}
+	}
+	this.channels[topic][channel] = struct{}{}
+	return missed, resumed, channel, func() {
+		this.lock.Lock()
+		defer this.lock.Unlock()
+		this.unsubscribeLocked(topic, channel)
+	}
+}
+
+// LastEventId is the resume token of the current state of any topic, the subscriber obtaining
+// the state by other means uses it to resume after reconnecting
+func (this *Broadcaster[Data]) LastEventId() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return fmt.Sprintf("%v-%v", this.epoch, this.sequence)
+}
+
+// Close disconnects all subscribers, e.g. when the server is shutting down
+func (this *Broadcaster[Data]) Close() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.closed = true
+	for topic, channels := range this.channels {
+		for channel := range channels {
+			this.unsubscribeLocked(topic, channel)
+		}
+	}
+}
+
+func (this *Broadcaster[Data]) unsubscribeLocked(topic string, channel chan Event[Data]) {
+	if _, ok := this.channels[topic][channel]; !ok {
+		return
+	}
+	delete(this.channels[topic], channel)
+	close(channel)
+}
+
+func (this *Broadcaster[Data]) parseId(id string) (uint64, bool) {
+	epoch, sequence, found := strings.Cut(id, "-")
+	if !found || epoch != this.epoch {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(sequence, 10, 64)
+	return value, err == nil
+}
@@ -0,0 +1,83 @@
+package broadcast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BroadcastSuite struct {
+	suite.Suite
+	sut *Broadcaster[string]
+}
+
+func TestBroadcastSuite(t *testing.T) {
+	suite.Run(t, new(BroadcastSuite))
+}
+
+func (suite *BroadcastSuite) SetupTest() {
+	suite.sut = New[string](2)
+}
+
+func (suite *BroadcastSuite) Test_Subscribe_ReceivesPublishedEvents() {
+	// ARRANGE
+	_, _, events, cancel := suite.sut.Subscribe("topic", "")
+	defer cancel()
+
+	// ACT
+	suite.sut.Publish("other", "ignored")
+	suite.sut.Publish("topic", "first")
+
+	// ASSERT
+	event := <-events
+	suite.Equal("first", event.Data)
+}
+
+func (suite *BroadcastSuite) Test_Subscribe_ResumesMissedEvents() {
+	// ARRANGE
+	token := suite.sut.LastEventId()
+	suite.sut.Publish("topic", "first")
+	suite.sut.Publish("other", "ignored")
+	suite.sut.Publish("topic", "second")
+
+	// ACT
+	missed, resumed, _, cancel := suite.sut.Subscribe("topic", token)
+	defer cancel()
+
+	// ASSERT
+	suite.True(resumed)
+	suite.Require().Len(missed, 2)
+	suite.Equal("first", missed[0].Data)
+	suite.Equal("second", missed[1].Data)
+}
+
+func (suite *BroadcastSuite) Test_Subscribe_NotResumedWhenEventsDropped() {
+	// ARRANGE
+	token := suite.sut.LastEventId()
+	for _, data := range []string{"first", "second", "third"} {
+		suite.sut.Publish("topic", data)
+	}
+
+	// ACT
+	_, resumed, _, cancel := suite.sut.Subscribe("topic", token)
+	defer cancel()
+	_, resumedUnknown, _, cancelUnknown := suite.sut.Subscribe("topic", "other-process-1")
+	defer cancelUnknown()
+
+	// ASSERT
+	suite.False(resumed)
+	suite.False(resumedUnknown)
+}
+
+func (suite *BroadcastSuite) Test_Close_DisconnectsSubscribers() {
+	// ARRANGE
+	_, _, events, cancel := suite.sut.Subscribe("topic", "")
+	defer cancel()
+
+	// ACT
+	suite.sut.Close()
+
+	// ASSERT
+	_, open := <-events
+	suite.False(open)
+}
@@ -0,0 +1,41 @@
+package db_service
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// storedFieldName resolves the name under which the field of the document is serialized.
+// The name is taken from the struct tag with the given key, if there is no such tag
+// the defaultName function is applied to the Go field name.
+func storedFieldName[DocType interface{}](field string, tagKey string, defaultName func(string) string) string {
+	docType := reflect.TypeOf((*DocType)(nil)).Elem()
+	if docType.Kind() != reflect.Struct {
+		return defaultName(field)
+	}
+	structField, ok := docType.FieldByName(field)
+	if !ok {
+		return defaultName(field)
+	}
+	if tag, ok := structField.Tag.Lookup(tagKey); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return defaultName(field)
+}
+
+func identity(name string) string {
+	return name
+}
+
+// sortedKeys lists the properties in a stable order
+func sortedKeys(properties map[string]interface{}) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,377 @@
+package db_service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type MemoryServiceConfig struct {
+	// Path to the JSON file with an array of documents loaded at startup. Optional.
+	SnapshotFile string
+}
+
+// memorySvc keeps the documents in the process memory. Documents are stored serialized
+// so that callers never share the instances with the store, the same way as with mongoSvc.
+type memorySvc[DocType interface{}] struct {
+	MemoryServiceConfig
+	documents map[string][]byte
+	lock      sync.RWMutex
+}
+
+func NewMemoryService[DocType interface{}](config MemoryServiceConfig) DbService[DocType] {
+	svc := &memorySvc[DocType]{}
+	svc.MemoryServiceConfig = config
+	svc.documents = map[string][]byte{}
+
+	if svc.SnapshotFile != "" {
+		if err := svc.loadSnapshot(); err != nil {
+			log.Printf("Failed to load snapshot %v: %v", svc.SnapshotFile, err)
+		} else {
+			log.Printf("Loaded %v documents from snapshot %v", len(svc.documents), svc.SnapshotFile)
+		}
+	}
+
+	log.Printf("Using in-memory database")
+	return svc
+}
+
+func (this *memorySvc[DocType]) loadSnapshot() error {
+	content, err := os.ReadFile(this.SnapshotFile)
+	if err != nil {
+		return err
+	}
+
+	var documents []json.RawMessage
+	if err := json.Unmarshal(content, &documents); err != nil {
+		return err
+	}
+
+	for _, raw := range documents {
+		var key struct {
+			Id string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return err
+		}
+		if key.Id == "" {
+			return fmt.Errorf("snapshot document without id: %s", raw)
+		}
+		// round trip through DocType to drop unknown properties
+		var document DocType
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return err
+		}
+		if err := this.store(key.Id, &document); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *memorySvc[DocType]) store(id string, document *DocType) error {
+	content, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	this.documents[id] = content
+	return nil
+}
+
+func (this *memorySvc[DocType]) load(id string) (*DocType, error) {
+	content, ok := this.documents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var document *DocType
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func (this *memorySvc[DocType]) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (this *memorySvc[DocType]) Ping(ctx context.Context) error {
+	return nil
+}
+
+// EnsureIndexes - the ids are unique keys of the map, there are no other indexes
+func (this *memorySvc[DocType]) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (this *memorySvc[DocType]) CreateDocument(ctx context.Context, id string, document *DocType) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if _, ok := this.documents[id]; ok {
+		return ErrConflict
+	}
+	return this.store(id, document)
+}
+
+func (this *memorySvc[DocType]) FindDocument(ctx context.Context, id string) (*DocType, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.load(id)
+}
+
+func (this *memorySvc[DocType]) ListDocuments(ctx context.Context, query ListQuery) ([]*DocType, int64, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	type candidate struct {
+		id         string
+		properties map[string]interface{}
+	}
+	candidates := []candidate{}
+	for id, content := range this.documents {
+		var properties map[string]interface{}
+		if err := json.Unmarshal(content, &properties); err != nil {
+			return nil, 0, err
+		}
+		if matchesQuery[DocType](properties, query) {
+			candidates = append(candidates, candidate{id, properties})
+		}
+	}
+
+	sortBy := storedFieldName[DocType](query.SortBy, "json", identity)
+	slices.SortFunc(candidates, func(left, right candidate) int {
+		if sortBy != "" {
+			if order := compareProperties(left.properties[sortBy], right.properties[sortBy]); order != 0 {
+				if query.Descending {
+					return -order
+				}
+				return order
+			}
+		}
+		// stable order of pages
+		return strings.Compare(left.id, right.id)
+	})
+
+	total := int64(len(candidates))
+	candidates = candidates[min(query.Offset, total):]
+	if query.Limit > 0 && int64(len(candidates)) > query.Limit {
+		candidates = candidates[:query.Limit]
+	}
+
+	documents := make([]*DocType, 0, len(candidates))
+	for _, current := range candidates {
+		document, err := this.load(current.id)
+		if err != nil {
+			return nil, 0, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, total, nil
+}
+
+// matchesQuery evaluates the filters of the query on the generic representation of the document
+func matchesQuery[DocType interface{}](properties map[string]interface{}, query ListQuery) bool {
+	for property, value := range query.Contains {
+		text, ok := properties[property].(string)
+		if !ok || !strings.Contains(strings.ToLower(text), strings.ToLower(value)) {
+			return false
+		}
+	}
+	for field, value := range query.Equals {
+		text, ok := properties[storedFieldName[DocType](field, "json", identity)].(string)
+		if !ok || text != value {
+			return false
+		}
+	}
+	for field, timeRange := range query.Between {
+		if timeRange.From.IsZero() && timeRange.Until.IsZero() {
+			continue
+		}
+		text, _ := properties[storedFieldName[DocType](field, "json", identity)].(string)
+		value, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil || !timeRange.Contains(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *memorySvc[DocType]) UpdateDocument(ctx context.Context, id string, document *DocType) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	current, err := this.load(id)
+	if err != nil {
+		return err
+	}
+
+	versioned, isVersioned := any(document).(Versioned)
+	if !isVersioned {
+		return this.store(id, document)
+	}
+
+	expectedVersion := versioned.GetVersion()
+	if any(current).(Versioned).GetVersion() != expectedVersion {
+		return ErrVersionMismatch
+	}
+
+	versioned.SetVersion(expectedVersion + 1)
+	if err := this.store(id, document); err != nil {
+		versioned.SetVersion(expectedVersion)
+		return err
+	}
+	return nil
+}
+
+func (this *memorySvc[DocType]) PushArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	return this.patch(id, expectedVersion, func(properties map[string]interface{}) error {
+		array = storedFieldName[DocType](array, "json", identity)
+		elements, _ := properties[array].([]interface{})
+		if indexOfElement(elements, elementId) >= 0 {
+			return ErrConflict
+		}
+		value, err := toProperty(element)
+		if err != nil {
+			return err
+		}
+		properties[array] = append(elements, value)
+		return nil
+	})
+}
+
+func (this *memorySvc[DocType]) PullArrayElement(ctx context.Context, id string, array string, elementId string, expectedVersion int64) error {
+	return this.patch(id, expectedVersion, func(properties map[string]interface{}) error {
+		array = storedFieldName[DocType](array, "json", identity)
+		elements, _ := properties[array].([]interface{})
+		index := indexOfElement(elements, elementId)
+		if index < 0 {
+			return ErrNotFound
+		}
+		properties[array] = slices.Delete(elements, index, index+1)
+		return nil
+	})
+}
+
+func (this *memorySvc[DocType]) SetArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	return this.patch(id, expectedVersion, func(properties map[string]interface{}) error {
+		array = storedFieldName[DocType](array, "json", identity)
+		elements, _ := properties[array].([]interface{})
+		index := indexOfElement(elements, elementId)
+		if index < 0 {
+			return ErrNotFound
+		}
+		value, err := toProperty(element)
+		if err != nil {
+			return err
+		}
+		elements[index] = value
+		return nil
+	})
+}
+
+func (this *memorySvc[DocType]) SetProperty(ctx context.Context, id string, property string, value interface{}, expectedVersion int64) error {
+	return this.SetProperties(ctx, id, map[string]interface{}{property: value}, expectedVersion)
+}
+
+func (this *memorySvc[DocType]) SetProperties(ctx context.Context, id string, properties map[string]interface{}, expectedVersion int64) error {
+	return this.patch(id, expectedVersion, func(stored map[string]interface{}) error {
+		for property, value := range properties {
+			converted, err := toProperty(value)
+			if err != nil {
+				return err
+			}
+			stored[storedFieldName[DocType](property, "json", identity)] = converted
+		}
+		return nil
+	})
+}
+
+// patch applies the change to the generic representation of the stored document and increments
+// the version of Versioned documents, which must have the expectedVersion
+func (this *memorySvc[DocType]) patch(id string, expectedVersion int64, change func(properties map[string]interface{}) error) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	current, err := this.load(id)
+	if err != nil {
+		return err
+	}
+	if versioned, isVersioned := any(current).(Versioned); isVersioned && versioned.GetVersion() != expectedVersion {
+		return ErrVersionMismatch
+	}
+
+	var properties map[string]interface{}
+	if err := json.Unmarshal(this.documents[id], &properties); err != nil {
+		return err
+	}
+	if err := change(properties); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+	var document DocType
+	if err := json.Unmarshal(content, &document); err != nil {
+		return err
+	}
+	if versioned, isVersioned := any(&document).(Versioned); isVersioned {
+		versioned.SetVersion(versioned.GetVersion() + 1)
+	}
+	return this.store(id, &document)
+}
+
+// compareProperties orders the numbers and the times by their values, the times are stored as
+// RFC 3339 strings which differ in the fraction digits and the zone offsets
+func compareProperties(left interface{}, right interface{}) int {
+	if leftNumber, ok := left.(float64); ok {
+		if rightNumber, ok := right.(float64); ok {
+			return cmp.Compare(leftNumber, rightNumber)
+		}
+	}
+	leftText, rightText := fmt.Sprint(left), fmt.Sprint(right)
+	if leftTime, err := time.Parse(time.RFC3339Nano, leftText); err == nil {
+		if rightTime, err := time.Parse(time.RFC3339Nano, rightText); err == nil {
+			return leftTime.Compare(rightTime)
+		}
+	}
+	return strings.Compare(leftText, rightText)
+}
+
+func indexOfElement(elements []interface{}, elementId string) int {
+	return slices.IndexFunc(elements, func(element interface{}) bool {
+		properties, ok := element.(map[string]interface{})
+		return ok && properties["id"] == elementId
+	})
+}
+
+// toProperty converts the value to its generic JSON representation
+func toProperty(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var property interface{}
+	err = json.Unmarshal(content, &property)
+	return property, err
+}
+
+func (this *memorySvc[DocType]) DeleteDocument(ctx context.Context, id string) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if _, ok := this.documents[id]; !ok {
+		return ErrNotFound
+	}
+	delete(this.documents, id)
+	return nil
+}
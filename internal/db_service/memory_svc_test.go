@@ -0,0 +1,231 @@
+package db_service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type testItem struct {
+	Id    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type testDocument struct {
+	Id      string     `json:"id"`
+	Name    string     `json:"name"`
+	Items   []testItem `json:"items,omitempty"`
+	Created time.Time  `json:"created"`
+	Version int64      `json:"version,omitempty"`
+}
+
+func (this *testDocument) GetVersion() int64 {
+	return this.Version
+}
+
+func (this *testDocument) SetVersion(version int64) {
+	this.Version = version
+}
+
+type MemorySvcSuite struct {
+	suite.Suite
+	sut DbService[testDocument]
+}
+
+func TestMemorySvcSuite(t *testing.T) {
+	suite.Run(t, new(MemorySvcSuite))
+}
+
+func (suite *MemorySvcSuite) SetupTest() {
+	suite.sut = NewMemoryService[testDocument](MemoryServiceConfig{})
+}
+
+func (suite *MemorySvcSuite) Test_CreateDocument_ConflictOnDuplicateId() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"}))
+
+	// ACT
+	err := suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"})
+
+	// ASSERT
+	suite.Equal(ErrConflict, err)
+}
+
+func (suite *MemorySvcSuite) Test_FindDocument_ReturnsCopy() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc", Name: "original"}))
+
+	// ACT
+	document, _ := suite.sut.FindDocument(ctx, "doc")
+	document.Name = "changed"
+	stored, err := suite.sut.FindDocument(ctx, "doc")
+
+	// ASSERT
+	suite.NoError(err)
+	suite.Equal("original", stored.Name)
+}
+
+func (suite *MemorySvcSuite) Test_UpdateDocument_VersionMismatch() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"}))
+	first, _ := suite.sut.FindDocument(ctx, "doc")
+	second, _ := suite.sut.FindDocument(ctx, "doc")
+	suite.Require().NoError(suite.sut.UpdateDocument(ctx, "doc", first))
+
+	// ACT
+	err := suite.sut.UpdateDocument(ctx, "doc", second)
+
+	// ASSERT
+	suite.Equal(ErrVersionMismatch, err)
+	suite.Equal(int64(1), first.Version)
+}
+
+func (suite *MemorySvcSuite) Test_NotFound() {
+	ctx := context.Background()
+
+	_, err := suite.sut.FindDocument(ctx, "missing")
+	suite.Equal(ErrNotFound, err)
+	suite.Equal(ErrNotFound, suite.sut.UpdateDocument(ctx, "missing", &testDocument{}))
+	suite.Equal(ErrNotFound, suite.sut.DeleteDocument(ctx, "missing"))
+}
+
+func (suite *MemorySvcSuite) Test_LoadSnapshot() {
+	// ARRANGE
+	snapshot := filepath.Join(suite.T().TempDir(), "snapshot.json")
+	content := `[{"id": "first", "name": "First"}, {"id": "second", "name": "Second"}]`
+	suite.Require().NoError(os.WriteFile(snapshot, []byte(content), 0600))
+
+	// ACT
+	sut := NewMemoryService[testDocument](MemoryServiceConfig{SnapshotFile: snapshot})
+	document, err := sut.FindDocument(context.Background(), "second")
+
+	// ASSERT
+	suite.NoError(err)
+	suite.Equal("Second", document.Name)
+}
+
+func (suite *MemorySvcSuite) Test_ArrayElements() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"}))
+
+	// ACT
+	suite.NoError(suite.sut.PushArrayElement(ctx, "doc", "Items", "a", testItem{Id: "a", Value: "first"}, 0))
+	suite.NoError(suite.sut.PushArrayElement(ctx, "doc", "Items", "b", testItem{Id: "b", Value: "second"}, 1))
+	conflictErr := suite.sut.PushArrayElement(ctx, "doc", "Items", "a", testItem{Id: "a"}, 2)
+	suite.NoError(suite.sut.SetArrayElement(ctx, "doc", "Items", "b", testItem{Id: "b", Value: "changed"}, 2))
+	mismatchErr := suite.sut.PullArrayElement(ctx, "doc", "Items", "a", 2)
+	suite.NoError(suite.sut.PullArrayElement(ctx, "doc", "Items", "a", 3))
+	notFoundErr := suite.sut.PullArrayElement(ctx, "doc", "Items", "a", 4)
+	document, err := suite.sut.FindDocument(ctx, "doc")
+
+	// ASSERT
+	suite.Equal(ErrConflict, conflictErr)
+	suite.Equal(ErrVersionMismatch, mismatchErr)
+	suite.Equal(ErrNotFound, notFoundErr)
+	suite.NoError(err)
+	suite.Equal([]testItem{{Id: "b", Value: "changed"}}, document.Items)
+	suite.Equal(int64(4), document.Version)
+}
+
+func (suite *MemorySvcSuite) Test_SetProperty_VersionMismatch() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"}))
+	suite.Require().NoError(suite.sut.SetProperty(ctx, "doc", "Name", "first", 0))
+
+	// ACT
+	err := suite.sut.SetProperty(ctx, "doc", "Name", "second", 0)
+
+	// ASSERT
+	suite.Equal(ErrVersionMismatch, err)
+	document, _ := suite.sut.FindDocument(ctx, "doc")
+	suite.Equal("first", document.Name)
+}
+
+func (suite *MemorySvcSuite) Test_SetProperties_AllOrNothing() {
+	// ARRANGE
+	ctx := context.Background()
+	suite.Require().NoError(suite.sut.CreateDocument(ctx, "doc", &testDocument{Id: "doc"}))
+	properties := map[string]interface{}{"Name": "named", "Items": []testItem{{Id: "a"}}}
+
+	// ACT
+	mismatchErr := suite.sut.SetProperties(ctx, "doc", properties, 1)
+	err := suite.sut.SetProperties(ctx, "doc", properties, 0)
+
+	// ASSERT
+	suite.Equal(ErrVersionMismatch, mismatchErr)
+	suite.NoError(err)
+	document, _ := suite.sut.FindDocument(ctx, "doc")
+	suite.Equal("named", document.Name)
+	suite.Equal([]testItem{{Id: "a"}}, document.Items)
+	suite.Equal(int64(1), document.Version)
+}
+
+func (suite *MemorySvcSuite) Test_ListDocuments_SortedByTimeValue() {
+	// ARRANGE
+	ctx := context.Background()
+	second := time.Date(2038, 12, 24, 10, 0, 0, 0, time.UTC)
+	for _, document := range []testDocument{
+		// sorted as the strings, the fraction and the offset would come first
+		{Id: "a-fraction", Created: second.Add(500 * time.Millisecond)},
+		{Id: "b-offset", Created: second.Add(time.Hour).In(time.FixedZone("EST", -5*3600))},
+		{Id: "c-whole", Created: second},
+	} {
+		suite.Require().NoError(suite.sut.CreateDocument(ctx, document.Id, &document))
+	}
+
+	ids := func(query ListQuery) []string {
+		documents, _, err := suite.sut.ListDocuments(ctx, query)
+		suite.Require().NoError(err)
+		ids := []string{}
+		for _, document := range documents {
+			ids = append(ids, document.Id)
+		}
+		return ids
+	}
+
+	// ACT
+	ascending := ids(ListQuery{SortBy: "Created"})
+	descending := ids(ListQuery{SortBy: "Created", Descending: true, Limit: 2})
+
+	// ASSERT
+	suite.Equal([]string{"c-whole", "a-fraction", "b-offset"}, ascending)
+	suite.Equal([]string{"b-offset", "a-fraction"}, descending)
+}
+
+func (suite *MemorySvcSuite) Test_ListDocuments_EqualsAndBetween() {
+	// ARRANGE
+	ctx := context.Background()
+	day := time.Date(2038, 12, 24, 0, 0, 0, 0, time.UTC)
+	for _, document := range []testDocument{
+		{Id: "early", Name: "a", Created: day.Add(-time.Hour)},
+		{Id: "first", Name: "a", Created: day.Add(10 * time.Hour)},
+		{Id: "other", Name: "b", Created: day.Add(11 * time.Hour)},
+		{Id: "second", Name: "a", Created: day.Add(9 * time.Hour)},
+		{Id: "late", Name: "a", Created: day.Add(24 * time.Hour)},
+	} {
+		suite.Require().NoError(suite.sut.CreateDocument(ctx, document.Id, &document))
+	}
+
+	// ACT
+	documents, total, err := suite.sut.ListDocuments(ctx, ListQuery{
+		Equals:  map[string]string{"Name": "a"},
+		Between: map[string]TimeRange{"Created": {From: day, Until: day.Add(24 * time.Hour)}},
+		SortBy:  "Created",
+	})
+
+	// ASSERT
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), total)
+	suite.Require().Len(documents, 2)
+	suite.Equal("second", documents[0].Id)
+	suite.Equal("first", documents[1].Id)
+}
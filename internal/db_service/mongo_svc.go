@@ -5,26 +5,88 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type DbService[DocType interface{}] interface {
 	CreateDocument(ctx context.Context, id string, document *DocType) error
 	FindDocument(ctx context.Context, id string) (*DocType, error)
+	ListDocuments(ctx context.Context, query ListQuery) (documents []*DocType, total int64, err error)
 	UpdateDocument(ctx context.Context, id string, document *DocType) error
 	DeleteDocument(ctx context.Context, id string) error
 	Disconnect(ctx context.Context) error
+	// Ping verifies that the database is reachable and ready to use
+	Ping(ctx context.Context) error
+	// EnsureIndexes creates the indexes of the collection if they do not exist yet
+	EnsureIndexes(ctx context.Context) error
+
+	// Targeted updates of the document. Properties are identified by the Go field name of the DocType,
+	// elements of array properties are matched by their id property. Each operation is atomic and
+	// increments the version of Versioned documents. Versioned documents are updated only if the stored
+	// version equals expectedVersion, otherwise the operation fails with ErrVersionMismatch.
+
+	// PushArrayElement appends the element to the array, ErrConflict if the element with the same id exists
+	PushArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error
+	// PullArrayElement removes the element from the array, ErrNotFound if there is no such element
+	PullArrayElement(ctx context.Context, id string, array string, elementId string, expectedVersion int64) error
+	// SetArrayElement replaces the element of the array, ErrNotFound if there is no such element
+	SetArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error
+	// SetProperty replaces the property of the document
+	SetProperty(ctx context.Context, id string, property string, value interface{}, expectedVersion int64) error
+	// SetProperties replaces several properties of the document in one update
+	SetProperties(ctx context.Context, id string, properties map[string]interface{}, expectedVersion int64) error
+}
+
+// ListQuery selects the page of documents returned by ListDocuments
+type ListQuery struct {
+	// case insensitive substring match of the string property (by its stored name) to the value
+	Contains map[string]string
+	// exact match of the string property, identified by the Go field name of the DocType, to the value
+	Equals map[string]string
+	// time property, identified by the Go field name of the DocType, within the range
+	Between map[string]TimeRange
+	// property to sort the documents by, ascending. Either the Go field name of the DocType or the stored name.
+	SortBy string
+	// sorts the documents by the SortBy property descending
+	Descending bool
+	// number of documents to skip
+	Offset int64
+	// maximal number of documents to return, zero means no limit
+	Limit int64
+}
+
+// TimeRange includes the From and excludes the Until time, zero time means unbounded
+type TimeRange struct {
+	From  time.Time
+	Until time.Time
+}
+
+func (this TimeRange) Contains(value time.Time) bool {
+	return (this.From.IsZero() || !value.Before(this.From)) && (this.Until.IsZero() || value.Before(this.Until))
 }
 
 var ErrNotFound = fmt.Errorf("document not found")
 var ErrConflict = fmt.Errorf("conflict: document already exists")
+var ErrVersionMismatch = fmt.Errorf("conflict: document was modified concurrently")
+
+// Versioned documents are protected by optimistic concurrency control. UpdateDocument
+// replaces them only if the stored version is equal to the version of the document
+// and increments the version on success.
+type Versioned interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
 
 type MongoServiceConfig struct {
 	ServerHost string
@@ -34,12 +96,22 @@ type MongoServiceConfig struct {
 	DbName     string
 	Collection string
 	Timeout    time.Duration
+	// indexes ensured when connecting, in addition to the unique index on the id field
+	Indexes []IndexDefinition
+}
+
+type IndexDefinition struct {
+	// stored names of the indexed properties, all ascending
+	Keys   []string
+	Unique bool
 }
 
 type mongoSvc[DocType interface{}] struct {
 	MongoServiceConfig
 	client     atomic.Pointer[mongo.Client]
 	clientLock sync.Mutex
+	// set once the unique index on the id is confirmed, until then the ids are checked before inserts
+	uniqueIds atomic.Bool
 }
 
 func NewMongoService[DocType interface{}](config MongoServiceConfig) DbService[DocType] {
@@ -93,6 +165,22 @@ func NewMongoService[DocType interface{}](config MongoServiceConfig) DbService[D
 		}
 	}
 
+	if len(svc.Indexes) == 0 {
+		// comma separated list of indexes, compound keys are joined by '+', unique index is suffixed by '!'
+		// e.g. "name,roomNumber+name!"
+		for _, index := range strings.Split(enviro("AMBULANCE_API_MONGODB_INDEXES", ""), ",") {
+			index = strings.TrimSpace(index)
+			if index == "" {
+				continue
+			}
+			unique := strings.HasSuffix(index, "!")
+			svc.Indexes = append(svc.Indexes, IndexDefinition{
+				Keys:   strings.Split(strings.TrimSuffix(index, "!"), "+"),
+				Unique: unique,
+			})
+		}
+	}
+
 	log.Printf(
 		"MongoDB config: //%v@%v:%v/%v/%v",
 		svc.UserName,
@@ -132,11 +220,110 @@ func (this *mongoSvc[DocType]) connect(ctx context.Context) (*mongo.Client, erro
 	if client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetConnectTimeout(10*time.Second)); err != nil {
 		return nil, err
 	} else {
+		if err := this.ensureIndexes(ctx, client); err != nil {
+			// the service reports not being ready by Ping until the indexes are ensured
+			log.Printf("Failed to ensure indexes of the collection %v: %v", this.Collection, err)
+		}
 		this.client.Store(client)
 		return client, nil
 	}
 }
 
+func (this *mongoSvc[DocType]) EnsureIndexes(ctx context.Context) error {
+	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
+	defer contextCancel()
+	client, err := this.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if this.uniqueIds.Load() {
+		return nil
+	}
+	return this.ensureIndexes(ctx, client)
+}
+
+// ensureIndexes creates the unique index on the id field and the configured indexes, if they do not exist yet
+func (this *mongoSvc[DocType]) ensureIndexes(ctx context.Context, client *mongo.Client) error {
+	collection := client.Database(this.DbName).Collection(this.Collection)
+
+	// databases initialized by the former init-db.js have the non-unique index on the id, the unique index
+	// with the same keys cannot be created next to it
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+	nonUniqueIdIndex := ""
+	for _, spec := range specs {
+		keys, _ := spec.KeysDocument.Elements()
+		if len(keys) == 1 && keys[0].Key() == "id" && (spec.Unique == nil || !*spec.Unique) {
+			nonUniqueIdIndex = spec.Name
+		}
+	}
+	if nonUniqueIdIndex != "" {
+		// keep the index if the unique one cannot replace it
+		if duplicateId, err := this.duplicateId(ctx, collection); err != nil {
+			return err
+		} else if duplicateId != nil {
+			return fmt.Errorf(
+				"several documents of the collection %v have the id %v, remove the duplicates so that the index %v can be replaced by the unique one",
+				this.Collection, duplicateId, nonUniqueIdIndex)
+		}
+		if _, err := collection.Indexes().DropOne(ctx, nonUniqueIdIndex); err != nil {
+			return err
+		}
+		log.Printf("Dropped non-unique index %v of the collection %v", nonUniqueIdIndex, this.Collection)
+	}
+
+	models := []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}}
+	for _, index := range this.Indexes {
+		keys := bson.D{}
+		for _, key := range index.Keys {
+			keys = append(keys, bson.E{Key: key, Value: 1})
+		}
+		models = append(models, mongo.IndexModel{
+			Keys:    keys,
+			Options: options.Index().SetUnique(index.Unique),
+		})
+	}
+
+	names, err := collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		if nonUniqueIdIndex != "" {
+			// e.g. the duplicate was inserted after the check, restore the dropped index
+			if _, restoreErr := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "id", Value: 1}},
+				Options: options.Index().SetName(nonUniqueIdIndex),
+			}); restoreErr != nil {
+				log.Printf("Failed to restore index %v of the collection %v: %v", nonUniqueIdIndex, this.Collection, restoreErr)
+			}
+		}
+		return err
+	}
+	log.Printf("Ensured indexes %v of the collection %v", names, this.Collection)
+	this.uniqueIds.Store(true)
+	return nil
+}
+
+// duplicateId finds any id shared by several documents of the collection, nil if the ids are unique
+func (this *mongoSvc[DocType]) duplicateId(ctx context.Context, collection *mongo.Collection) (interface{}, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$id"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+		{{Key: "$limit", Value: 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if !cursor.Next(ctx) {
+		return nil, cursor.Err()
+	}
+	return cursor.Current.Lookup("_id"), nil
+}
+
 func (this *mongoSvc[DocType]) Disconnect(ctx context.Context) error {
 	client := this.client.Load()
 
@@ -155,6 +342,24 @@ func (this *mongoSvc[DocType]) Disconnect(ctx context.Context) error {
 	return nil
 }
 
+func (this *mongoSvc[DocType]) Ping(ctx context.Context) error {
+	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
+	defer contextCancel()
+	client, err := this.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		return err
+	}
+	if !this.uniqueIds.Load() {
+		if err := this.ensureIndexes(ctx, client); err != nil {
+			return fmt.Errorf("indexes of the collection %v are not ensured: %w", this.Collection, err)
+		}
+	}
+	return nil
+}
+
 func (this *mongoSvc[DocType]) CreateDocument(ctx context.Context, id string, document *DocType) error {
 	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
 	defer contextCancel()
@@ -164,17 +369,21 @@ func (this *mongoSvc[DocType]) CreateDocument(ctx context.Context, id string, do
 	}
 	db := client.Database(this.DbName)
 	collection := db.Collection(this.Collection)
-	result := collection.FindOne(ctx, bson.D{{Key: "id", Value: id}})
-	switch result.Err() {
-	case nil: // no error means there is conflicting document
-		return ErrConflict
-	case mongo.ErrNoDocuments:
-		// do nothing, this is expected
-	default: // other errors - return them
-		return result.Err()
-	}
 
+	// uniqueness of the id is guaranteed by the unique index, the lookup covers the time before it is confirmed
+	if !this.uniqueIds.Load() {
+		switch err := collection.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Err(); err {
+		case nil:
+			return ErrConflict
+		case mongo.ErrNoDocuments:
+		default:
+			return err
+		}
+	}
 	_, err = collection.InsertOne(ctx, document)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
 	return err
 }
 
@@ -202,6 +411,64 @@ func (this *mongoSvc[DocType]) FindDocument(ctx context.Context, id string) (*Do
 	return document, nil
 }
 
+func (this *mongoSvc[DocType]) ListDocuments(ctx context.Context, query ListQuery) ([]*DocType, int64, error) {
+	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
+	defer contextCancel()
+	client, err := this.connect(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	db := client.Database(this.DbName)
+	collection := db.Collection(this.Collection)
+
+	filter := bson.D{}
+	for property, value := range query.Contains {
+		filter = append(filter, bson.E{Key: property, Value: primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}})
+	}
+	for field, value := range query.Equals {
+		filter = append(filter, bson.E{Key: mongoFieldName[DocType](field), Value: value})
+	}
+	for field, timeRange := range query.Between {
+		condition := bson.D{}
+		if !timeRange.From.IsZero() {
+			condition = append(condition, bson.E{Key: "$gte", Value: timeRange.From})
+		}
+		if !timeRange.Until.IsZero() {
+			condition = append(condition, bson.E{Key: "$lt", Value: timeRange.Until})
+		}
+		if len(condition) > 0 {
+			filter = append(filter, bson.E{Key: mongoFieldName[DocType](field), Value: condition})
+		}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().SetSkip(query.Offset)
+	if query.Limit > 0 {
+		findOptions.SetLimit(query.Limit)
+	}
+	if query.SortBy != "" {
+		direction := 1
+		if query.Descending {
+			direction = -1
+		}
+		findOptions.SetSort(bson.D{{Key: mongoFieldName[DocType](query.SortBy), Value: direction}})
+	}
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	documents := []*DocType{}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, 0, err
+	}
+	return documents, total, nil
+}
+
 func (this *mongoSvc[DocType]) UpdateDocument(ctx context.Context, id string, document *DocType) error {
 	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
 	defer contextCancel()
@@ -211,18 +478,163 @@ func (this *mongoSvc[DocType]) UpdateDocument(ctx context.Context, id string, do
 	}
 	db := client.Database(this.DbName)
 	collection := db.Collection(this.Collection)
-	result := collection.FindOne(ctx, bson.D{{Key: "id", Value: id}})
-	switch result.Err() {
-	case nil:
-	case mongo.ErrNoDocuments:
-		return ErrNotFound
-	default: // other errors - return them
-		return result.Err()
+
+	versioned, isVersioned := any(document).(Versioned)
+	if !isVersioned {
+		result := collection.FindOne(ctx, bson.D{{Key: "id", Value: id}})
+		switch result.Err() {
+		case nil:
+		case mongo.ErrNoDocuments:
+			return ErrNotFound
+		default: // other errors - return them
+			return result.Err()
+		}
+		_, err = collection.ReplaceOne(ctx, bson.D{{Key: "id", Value: id}}, document)
+		return err
+	}
+
+	expectedVersion := versioned.GetVersion()
+	filter := versionFilter(id, expectedVersion)
+
+	versioned.SetVersion(expectedVersion + 1)
+	result, err := collection.ReplaceOne(ctx, filter, document)
+	if err == nil && result.MatchedCount == 0 {
+		// distinguish between missing document and concurrent modification
+		switch err = collection.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Err(); err {
+		case nil:
+			err = ErrVersionMismatch
+		case mongo.ErrNoDocuments:
+			err = ErrNotFound
+		}
+	}
+	if err != nil {
+		versioned.SetVersion(expectedVersion)
 	}
-	_, err = collection.ReplaceOne(ctx, bson.D{{Key: "id", Value: id}}, document)
 	return err
 }
 
+func (this *mongoSvc[DocType]) PushArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	array = mongoFieldName[DocType](array)
+	elementFilter := bson.D{{Key: array + ".id", Value: bson.D{{Key: "$ne", Value: elementId}}}}
+	return this.updateOne(ctx, id, expectedVersion, elementFilter, this.pushUpdate(array, element), ErrConflict)
+}
+
+// pushUpdate appends the element by the update pipeline, unlike $push it accepts also the array
+// stored as null or missing, e.g. in the documents created before the array was introduced
+func (this *mongoSvc[DocType]) pushUpdate(array string, element interface{}) mongo.Pipeline {
+	set := bson.D{{Key: array, Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+		bson.D{{Key: "$ifNull", Value: bson.A{"$" + array, bson.A{}}}},
+		// literal so that the string values starting with $ are not taken as the field paths
+		bson.A{bson.D{{Key: "$literal", Value: element}}},
+	}}}}}
+	if _, isVersioned := any((*DocType)(nil)).(Versioned); isVersioned {
+		set = append(set, bson.E{Key: "version", Value: bson.D{{Key: "$add", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$version", 0}}}, 1,
+		}}}})
+	}
+	return mongo.Pipeline{{{Key: "$set", Value: set}}}
+}
+
+func (this *mongoSvc[DocType]) PullArrayElement(ctx context.Context, id string, array string, elementId string, expectedVersion int64) error {
+	array = mongoFieldName[DocType](array)
+	elementFilter := bson.D{{Key: array + ".id", Value: elementId}}
+	update := this.withVersionIncrement(bson.D{{Key: "$pull", Value: bson.D{{Key: array, Value: bson.D{{Key: "id", Value: elementId}}}}}})
+	return this.updateOne(ctx, id, expectedVersion, elementFilter, update, ErrNotFound)
+}
+
+func (this *mongoSvc[DocType]) SetArrayElement(ctx context.Context, id string, array string, elementId string, element interface{}, expectedVersion int64) error {
+	array = mongoFieldName[DocType](array)
+	elementFilter := bson.D{{Key: array + ".id", Value: elementId}}
+	update := this.withVersionIncrement(bson.D{{Key: "$set", Value: bson.D{{Key: array + ".$", Value: element}}}})
+	return this.updateOne(ctx, id, expectedVersion, elementFilter, update, ErrNotFound)
+}
+
+func (this *mongoSvc[DocType]) SetProperty(ctx context.Context, id string, property string, value interface{}, expectedVersion int64) error {
+	return this.SetProperties(ctx, id, map[string]interface{}{property: value}, expectedVersion)
+}
+
+func (this *mongoSvc[DocType]) SetProperties(ctx context.Context, id string, properties map[string]interface{}, expectedVersion int64) error {
+	set := bson.D{}
+	for _, property := range sortedKeys(properties) {
+		set = append(set, bson.E{Key: mongoFieldName[DocType](property), Value: properties[property]})
+	}
+	update := this.withVersionIncrement(bson.D{{Key: "$set", Value: set}})
+	return this.updateOne(ctx, id, expectedVersion, bson.D{}, update, ErrVersionMismatch)
+}
+
+// updateOne applies the update to the document with the id matching the element filter, Versioned
+// documents must also have the expectedVersion. If no document matches, returns ErrNotFound when
+// the document does not exist, ErrVersionMismatch when it has other version, otherwise the errNotMatched.
+func (this *mongoSvc[DocType]) updateOne(
+	ctx context.Context,
+	id string,
+	expectedVersion int64,
+	elementFilter bson.D,
+	update interface{},
+	errNotMatched error,
+) error {
+	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
+	defer contextCancel()
+	client, err := this.connect(ctx)
+	if err != nil {
+		return err
+	}
+	db := client.Database(this.DbName)
+	collection := db.Collection(this.Collection)
+
+	_, isVersioned := any((*DocType)(nil)).(Versioned)
+	filter := bson.D{{Key: "id", Value: id}}
+	if isVersioned {
+		filter = versionFilter(id, expectedVersion)
+	}
+	result, err := collection.UpdateOne(ctx, append(filter, elementFilter...), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	stored, err := collection.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Raw()
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return ErrNotFound
+	case err != nil:
+		return err
+	case isVersioned && storedVersion(stored) != expectedVersion:
+		return ErrVersionMismatch
+	default:
+		return errNotMatched
+	}
+}
+
+// storedVersion reads the version of the stored document, documents created before
+// versioning was introduced have no version field
+func storedVersion(stored bson.Raw) int64 {
+	version, _ := stored.Lookup("version").AsInt64OK()
+	return version
+}
+
+func (this *mongoSvc[DocType]) withVersionIncrement(update bson.D) bson.D {
+	if _, isVersioned := any((*DocType)(nil)).(Versioned); isVersioned {
+		update = append(update, bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}})
+	}
+	return update
+}
+
+// versionFilter matches the document with the id and version
+func versionFilter(id string, version int64) bson.D {
+	if version == 0 {
+		// documents created before versioning was introduced have no version field
+		return bson.D{{Key: "id", Value: id}, {Key: "version", Value: bson.D{{Key: "$in", Value: bson.A{0, nil}}}}}
+	}
+	return bson.D{{Key: "id", Value: id}, {Key: "version", Value: version}}
+}
+
+// mongoFieldName resolves the field name used by the default bson struct codec
+func mongoFieldName[DocType interface{}](field string) string {
+	return storedFieldName[DocType](field, "bson", strings.ToLower)
+}
+
 func (this *mongoSvc[DocType]) DeleteDocument(ctx context.Context, id string) error {
 	ctx, contextCancel := context.WithTimeout(ctx, this.Timeout)
 	defer contextCancel()
@@ -0,0 +1,117 @@
+package db_service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type MongoSvcSuite struct {
+	suite.Suite
+	sut *mongoSvc[testDocument]
+}
+
+func TestMongoSvcSuite(t *testing.T) {
+	suite.Run(t, new(MongoSvcSuite))
+}
+
+func (suite *MongoSvcSuite) SetupTest() {
+	suite.sut = &mongoSvc[testDocument]{}
+}
+
+func (suite *MongoSvcSuite) Test_PushUpdate_AcceptsNullArray() {
+	// ARRANGE
+	item := testItem{Id: "a", Value: "$not-a-field-path"}
+
+	// ACT
+	update := suite.sut.pushUpdate(mongoFieldName[testDocument]("Items"), item)
+
+	// ASSERT
+	stage, err := bson.MarshalExtJSON(update[0], false, false)
+	suite.Require().NoError(err)
+	suite.JSONEq(`{"$set": {
+		"items": {"$concatArrays": [
+			{"$ifNull": ["$items", []]},
+			[{"$literal": {"id": "a", "value": "$not-a-field-path"}}]
+		]},
+		"version": {"$add": [{"$ifNull": ["$version", 0]}, 1]}
+	}}`, string(stage))
+}
+
+// withMockDeployment runs the test with the service connected to the mock deployment replaying the responses
+func (suite *MongoSvcSuite) withMockDeployment(test func(), responses ...bson.D) {
+	mt := mtest.New(suite.T(), mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("mock", func(mt *mtest.T) {
+		suite.sut.DbName = "db"
+		suite.sut.Collection = "documents"
+		suite.sut.Timeout = time.Second
+		suite.sut.client.Store(mt.Client)
+		mt.AddMockResponses(responses...)
+		test()
+	})
+}
+
+func (suite *MongoSvcSuite) Test_PushArrayElement_StaleVersionDetected() {
+	// ARRANGE
+	notMatched := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0})
+	stored := func(version int64) bson.D {
+		return mtest.CreateCursorResponse(0, "db.documents", mtest.FirstBatch, bson.D{
+			{Key: "id", Value: "doc"}, {Key: "version", Value: version},
+		})
+	}
+	var mismatchErr, conflictErr error
+
+	// ACT
+	suite.withMockDeployment(func() {
+		mismatchErr = suite.sut.PushArrayElement(context.Background(), "doc", "Items", "a", testItem{Id: "a"}, 2)
+		conflictErr = suite.sut.PushArrayElement(context.Background(), "doc", "Items", "a", testItem{Id: "a"}, 3)
+	}, notMatched, stored(3), notMatched, stored(3))
+
+	// ASSERT
+	suite.Equal(ErrVersionMismatch, mismatchErr)
+	suite.Equal(ErrConflict, conflictErr)
+}
+
+func (suite *MongoSvcSuite) Test_CreateDocument_DuplicateKeyIsConflict() {
+	// ARRANGE
+	suite.sut.uniqueIds.Store(true)
+	duplicateKey := mtest.CreateWriteErrorsResponse(mtest.WriteError{
+		Index: 0, Code: 11000, Message: "E11000 duplicate key error collection: db.documents index: id_1",
+	})
+	var err error
+
+	// ACT
+	suite.withMockDeployment(func() {
+		err = suite.sut.CreateDocument(context.Background(), "doc", &testDocument{Id: "doc"})
+	}, duplicateKey)
+
+	// ASSERT
+	suite.Equal(ErrConflict, err)
+}
+
+func (suite *MongoSvcSuite) Test_EnsureIndexes_DuplicateIdsKeepIndex() {
+	// ARRANGE
+	indexes := mtest.CreateCursorResponse(0, "db.documents", mtest.FirstBatch,
+		bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}},
+		bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "id", Value: 1}}}, {Key: "name", Value: "id_1"}},
+	)
+	duplicates := mtest.CreateCursorResponse(0, "db.documents", mtest.FirstBatch,
+		bson.D{{Key: "_id", Value: "doc"}, {Key: "count", Value: 2}},
+	)
+	var err error
+
+	// ACT
+	suite.withMockDeployment(func() {
+		// no response is prepared for dropping the index
+		err = suite.sut.ensureIndexes(context.Background(), suite.sut.client.Load())
+	}, indexes, duplicates)
+
+	// ASSERT
+	suite.ErrorContains(err, "remove the duplicates")
+	suite.ErrorContains(err, "id_1")
+	suite.False(suite.sut.uniqueIds.Load())
+}
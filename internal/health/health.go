@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check verifies availability of a dependency of the service, e.g. DbService.Ping
+type Check = func(ctx context.Context) error
+
+// Health tracks the readiness of the service and serves the liveness and readiness probes.
+// The service is not ready until SetReady(true) is called at the end of the startup and
+// shall be switched back to not ready at the beginning of the shutdown.
+type Health struct {
+	ready        atomic.Bool
+	checks       map[string]Check
+	checksLock   sync.RWMutex
+	CheckTimeout time.Duration
+}
+
+func New() *Health {
+	return &Health{
+		checks:       map[string]Check{},
+		CheckTimeout: 2 * time.Second,
+	}
+}
+
+func (this *Health) SetReady(ready bool) {
+	this.ready.Store(ready)
+}
+
+func (this *Health) IsReady() bool {
+	return this.ready.Load()
+}
+
+// AddCheck registers the dependency which must be available for the service to be ready
+func (this *Health) AddCheck(name string, check Check) {
+	this.checksLock.Lock()
+	defer this.checksLock.Unlock()
+	this.checks[name] = check
+}
+
+// HandleLiveness - the process is running and able to serve requests
+func (this *Health) HandleLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "UP"})
+}
+
+// HandleReadiness - the service finished startup, is not shutting down and its dependencies are available
+func (this *Health) HandleReadiness(ctx *gin.Context) {
+	if !this.IsReady() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "DOWN",
+			"message": "Service is starting or shutting down",
+		})
+		return
+	}
+
+	this.checksLock.RLock()
+	defer this.checksLock.RUnlock()
+
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), this.CheckTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	results := gin.H{}
+	for name, check := range this.checks {
+		if err := check(checkCtx); err != nil {
+			status = http.StatusServiceUnavailable
+			results[name] = gin.H{"status": "DOWN", "error": err.Error()}
+		} else {
+			results[name] = gin.H{"status": "UP"}
+		}
+	}
+
+	overall := "UP"
+	if status != http.StatusOK {
+		overall = "DOWN"
+	}
+	ctx.JSON(status, gin.H{
+		"status": overall,
+		"checks": results,
+	})
+}
@@ -26,6 +26,12 @@ switch ($command) {
             mongo down
         }
     }
+    "memory" {
+        $env:AMBULANCE_API_DB_BACKEND="memory"
+        $env:AMBULANCE_API_MEMORY_SNAPSHOT="${ProjectRoot}/deployments/snapshot/ambulances.json"
+        $env:AMBULANCE_API_MEMORY_CATALOGUE_SNAPSHOT="${ProjectRoot}/deployments/snapshot/conditions.json"
+        go run ${ProjectRoot}/cmd/ambulance-api-service
+    }
     "test" {
         go test -v ./...
     }